@@ -0,0 +1,168 @@
+package s3
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+const regexPatternPrefix = "regex:"
+
+var (
+	errInvalidAccessRules = errors.New(`aws s3: invalid "allowed_prefixes", "denied_prefixes", "allowed_patterns" or "denied_patterns" defined`)
+	errForbiddenKey       = client.HTTPResponseError{Code: http.StatusForbidden, Msg: "aws s3: access to the object key is not allowed"}
+)
+
+// AccessRules restricts the object keys the backend can read. A key is
+// forbidden when it matches any denied prefix or pattern or, if any allow
+// rule is defined, when it matches none of them.
+type AccessRules struct {
+	AllowedPrefixes []string
+	DeniedPrefixes  []string
+	AllowedPatterns []*keyPattern
+	DeniedPatterns  []*keyPattern
+}
+
+// keyPattern matches object keys against a glob or, when prefixed with
+// "regex:", a regular expression.
+type keyPattern struct {
+	glob  string
+	regex *regexp.Regexp
+}
+
+func (p *keyPattern) match(k string) bool {
+	if p.regex != nil {
+		return p.regex.MatchString(k)
+	}
+
+	ok, _ := path.Match(p.glob, k)
+	return ok
+}
+
+// allows reports whether the given key can be read.
+func (r *AccessRules) allows(k string) bool {
+	if r == nil {
+		return true
+	}
+
+	if hasPrefix(k, r.DeniedPrefixes) || matchesAny(k, r.DeniedPatterns) {
+		return false
+	}
+
+	if len(r.AllowedPrefixes) == 0 && len(r.AllowedPatterns) == 0 {
+		return true
+	}
+
+	return hasPrefix(k, r.AllowedPrefixes) || matchesAny(k, r.AllowedPatterns)
+}
+
+// checkAccess returns a 403 error when the access rules forbid the given key.
+func (b *backend) checkAccess(k string) error {
+	if !b.opts.AccessRules.allows(k) {
+		return errForbiddenKey
+	}
+
+	return nil
+}
+
+func hasPrefix(k string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesAny(k string, patterns []*keyPattern) bool {
+	for _, p := range patterns {
+		if p.match(k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAccessRules parses the access rules, failing on prefixes starting with a
+// slash, as object keys never do, and on invalid patterns.
+func getAccessRules(cfg map[string]interface{}) (*AccessRules, error) {
+	rules := &AccessRules{}
+	defined := false
+
+	for name, dst := range map[string]*[]string{
+		"allowed_prefixes": &rules.AllowedPrefixes,
+		"denied_prefixes":  &rules.DeniedPrefixes,
+	} {
+		v, ok := cfg[name]
+		if !ok {
+			continue
+		}
+
+		prefixes, err := getStrings(v)
+		if err != nil {
+			return nil, errInvalidAccessRules
+		}
+
+		for _, p := range prefixes {
+			if strings.HasPrefix(p, "/") {
+				return nil, errInvalidAccessRules
+			}
+		}
+
+		*dst = prefixes
+		defined = true
+	}
+
+	for name, dst := range map[string]*[]*keyPattern{
+		"allowed_patterns": &rules.AllowedPatterns,
+		"denied_patterns":  &rules.DeniedPatterns,
+	} {
+		v, ok := cfg[name]
+		if !ok {
+			continue
+		}
+
+		patterns, err := getStrings(v)
+		if err != nil {
+			return nil, errInvalidAccessRules
+		}
+
+		for _, p := range patterns {
+			kp, err := newKeyPattern(p)
+			if err != nil {
+				return nil, errInvalidAccessRules
+			}
+			*dst = append(*dst, kp)
+		}
+		defined = true
+	}
+
+	if !defined {
+		return nil, nil
+	}
+
+	return rules, nil
+}
+
+func newKeyPattern(p string) (*keyPattern, error) {
+	if strings.HasPrefix(p, regexPatternPrefix) {
+		re, err := regexp.Compile(strings.TrimPrefix(p, regexPatternPrefix))
+		if err != nil {
+			return nil, err
+		}
+
+		return &keyPattern{regex: re}, nil
+	}
+
+	if _, err := path.Match(p, ""); err != nil {
+		return nil, err
+	}
+
+	return &keyPattern{glob: p}, nil
+}
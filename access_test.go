@@ -0,0 +1,124 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_accessRules(t *testing.T) {
+	rules := map[string]interface{}{
+		"allowed_prefixes": []interface{}{"public/", "shared/"},
+		"denied_prefixes":  []interface{}{"public/internal/"},
+		"allowed_patterns": []interface{}{"reports/*.csv", `regex:^users/\d+\.json$`},
+		"denied_patterns":  []interface{}{"*/.env"},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		allowed bool
+	}{
+		{name: "allowed prefix, should fetch the object", path: "/public/sample", allowed: true},
+		{name: "allowed glob, should fetch the object", path: "/reports/2022.csv", allowed: true},
+		{name: "allowed regex, should fetch the object", path: "/users/42.json", allowed: true},
+		{name: "denied prefix, should return 403", path: "/public/internal/sample"},
+		{name: "denied glob, should return 403", path: "/shared/.env"},
+		{name: "no allowed rule matched, should return 403", path: "/private/sample"},
+		{name: "regex not matched, should return 403", path: "/users/admin.json"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				if tt.allowed {
+					expectGetObject(cl, tt.path[1:]).Times(1).Return(objectOutput(`{}`), nil)
+				}
+
+				cfg := map[string]interface{}{"bucket": "bucket1"}
+				for k, v := range rules {
+					cfg[k] = v
+				}
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: cfg}})
+
+				_, err := p(context.Background(), &proxy.Request{Path: tt.path})
+				if tt.allowed {
+					assert.NoError(t, err)
+					return
+				}
+
+				wantStatusCode(http.StatusForbidden)(t, err)
+			},
+		)
+	}
+}
+
+func TestBackendFactory_invalidAccessRules(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  map[string]interface{}
+	}{
+		{
+			name: "prefix starting with a slash",
+			cfg:  map[string]interface{}{"allowed_prefixes": []interface{}{"/public/"}},
+		},
+		{
+			name: "empty prefix",
+			cfg:  map[string]interface{}{"denied_prefixes": []interface{}{""}},
+		},
+		{
+			name: "prefixes not a list",
+			cfg:  map[string]interface{}{"allowed_prefixes": "public/"},
+		},
+		{
+			name: "invalid glob",
+			cfg:  map[string]interface{}{"allowed_patterns": []interface{}{"reports/[a-"}},
+		},
+		{
+			name: "invalid regex",
+			cfg:  map[string]interface{}{"denied_patterns": []interface{}{"regex:users/(\\d+"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				l := mocks.NewMockLogger(ctrl)
+				l.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "allowed_prefixes", "denied_prefixes", "allowed_patterns" or "denied_patterns" defined`),
+				).Times(1)
+
+				cfg := map[string]interface{}{"bucket": "bucket1"}
+				for k, v := range tt.cfg {
+					cfg[k] = v
+				}
+
+				b := s3.BackendFactory(l, noopBackendFactory)
+				b(
+					&config.Backend{
+						URLPattern:  "/some-endpoint",
+						ExtraConfig: map[string]interface{}{s3.Namespace: cfg},
+					},
+				)
+			},
+		)
+	}
+}
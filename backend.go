@@ -1,49 +1,239 @@
 package s3
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"strings"
+	"time"
 
+	"github.com/PaesslerAG/gval"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
 	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/encoding"
 	"github.com/luraproject/lura/v2/logging"
 	"github.com/luraproject/lura/v2/proxy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const Namespace = "github.com/jbactad/krakend-s3"
 
+// replicaHeader is the header reporting the bucket that served the response when replicas are defined.
+const replicaHeader = "X-S3-Replica"
+
+const (
+	// OperationGet reads the object, the default operation.
+	OperationGet = "get"
+	// OperationPut uploads the request body into the object.
+	OperationPut = "put"
+	// OperationPresignPost returns a presigned form to upload an object
+	// directly to s3.
+	OperationPresignPost = "presign_post"
+	// OperationCopy copies an object into another key.
+	OperationCopy = "copy"
+	// OperationMove copies an object into another key and deletes it.
+	OperationMove = "move"
+	// OperationGetTags returns the tags of the object.
+	OperationGetTags = "get_tags"
+	// OperationPutTags writes the tags of the request body into the object.
+	OperationPutTags = "put_tags"
+	// OperationBatchGet reads all the objects listed in the request.
+	OperationBatchGet = "batch_get"
+)
+
+var operations = map[string]bool{
+	OperationGet:         true,
+	OperationPut:         true,
+	OperationPresignPost: true,
+	OperationCopy:        true,
+	OperationMove:        true,
+	OperationGetTags:     true,
+	OperationPutTags:     true,
+	OperationBatchGet:    true,
+}
+
+// staleWarning is the Warning header value added to responses served from a stale cached copy.
+const staleWarning = `110 - "Response is Stale"`
+
 var (
-	errNoConfig      = errors.New("aws s3: no extra config defined")
-	errInvalidBucket = errors.New(`aws s3: invalid "bucket" defined`)
-	errInvalidConfig = errors.New("aws s3: invalid config")
+	errNoConfig            = errors.New("aws s3: no extra config defined")
+	errInvalidBucket       = errors.New(`aws s3: invalid "bucket" defined`)
+	errInvalidConfig       = errors.New("aws s3: invalid config")
+	errInvalidCache        = errors.New(`aws s3: invalid "cache_ttl" or "serve_stale_on_error" defined`)
+	errInvalidFallbackKeys = errors.New(`aws s3: invalid "fallback_key" or "fallback_keys" defined`)
+	errInvalidReplicas     = errors.New(`aws s3: invalid "replicas" defined`)
+	errInvalidKeys         = errors.New(`aws s3: invalid "keys" or "merge_strategy" defined`)
+	errInvalidCredentials  = errors.New(`aws s3: invalid "key" or "secret" defined`)
 )
 
 type ObjectGetter interface {
 	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
 }
 
+// ObjectSelector runs S3 Select queries against the objects. It is implemented
+// by the s3 client and only required when the "select" option is defined.
+type ObjectSelector interface {
+	SelectObjectContent(ctx context.Context, params *s3.SelectObjectContentInput, optFns ...func(*s3.Options)) (*s3.SelectObjectContentOutput, error)
+}
+
+// ObjectUploader uploads objects in one or multiple parts. It is implemented
+// by the s3 client and only required by the "put" operation.
+type ObjectUploader interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// ObjectCopier copies and deletes objects. It is implemented by the s3 client
+// and only required by the "copy" and "move" operations.
+type ObjectCopier interface {
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// ObjectTagger reads and writes the tags of the objects. It is implemented by
+// the s3 client and only required by the tagging operations or when the tags
+// are included in the responses.
+type ObjectTagger interface {
+	GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error)
+	PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error)
+}
+
 type Options struct {
-	AWSConfig     aws.Config
-	Bucket        string
-	PathExtension string
+	AWSConfig          aws.Config
+	Bucket             string
+	PathExtension      string
+	CacheTTL           time.Duration
+	ServeStaleOnError  time.Duration
+	FallbackKeys       []string
+	FallbackStatusCode int
+	IndexDocument      string
+	TryHTMLExtension   bool
+	ErrorDocument      string
+	Passthrough        bool
+	// Replicas are the buckets to fail over to, in order, when the primary
+	// one fails. Only their Bucket and AWSConfig are used.
+	Replicas        []Options
+	FailoverTimeout time.Duration
+	HedgeRequests   bool
+	// Keys are the objects fetched and merged into a single response instead
+	// of the one matching the request path.
+	Keys          []ObjectKey
+	MergeStrategy string
+	SkipMissing   bool
+	// ForwardCompressed sends compressed objects as they are in passthrough
+	// mode when the client accepts their encoding.
+	ForwardCompressed bool
+	// MaxDecompressedSize is the maximum size in bytes of the decompressed
+	// content of the compressed objects.
+	MaxDecompressedSize int64
+	// Select runs an S3 Select query on the object instead of fetching it.
+	Select *SelectOptions
+	// Extract is a JSONPath expression selecting the part of the decoded
+	// object to return. It may contain request param placeholders.
+	Extract      string
+	ExtractFirst bool
+	// LogLevel enables a structured log line for every s3 operation, written
+	// at this level, for a LogSampling fraction of the successful ones.
+	LogLevel    string
+	LogSampling float64
+	// AccessRules restricts the object keys that can be read, nil when any
+	// key is allowed.
+	AccessRules *AccessRules
+	// Tenant is the template resolving the tenant of a request, which is read
+	// from the bucket configured for it in Tenants, instead of Bucket. Only
+	// the Bucket and AWSConfig of the tenants are used.
+	Tenant  string
+	Tenants map[string]Options
+	// RequestPayer makes the backend pay for the requests to requester pays
+	// buckets. ExpectedBucketOwner is the account id that must own the bucket,
+	// sent with every s3 operation.
+	RequestPayer        bool
+	ExpectedBucketOwner string
+	// ValidateChecksum enables the checksum mode of GetObject and verifies the
+	// content of the objects against the returned checksums.
+	ValidateChecksum bool
+	// Operation is what the backend does with the object: read it, when
+	// empty or OperationGet, or write the request body into it.
+	Operation string
+	// PartSize and UploadConcurrency tune the multipart uploads of the put
+	// operation, using the upload manager defaults when zero.
+	PartSize          int64
+	UploadConcurrency int
+	// PostPolicy defines the conditions of the forms returned by the
+	// presign_post operation.
+	PostPolicy *PostPolicyOptions
+	// Copy defines the source and destination of the copy and move operations.
+	Copy *CopyOptions
+	// TagsMerge merges the tags written by the put_tags operation into the
+	// existing ones instead of replacing them.
+	TagsMerge bool
+	// TagsField and TagsHeader include the tags of the object in the data
+	// and the headers of the responses.
+	TagsField  string
+	TagsHeader string
+	// MaxBatchSize and BatchConcurrency limit the number of keys of a
+	// batch_get request and how many of them are fetched at a time.
+	MaxBatchSize     int
+	BatchConcurrency int
+	// Formats are the representations of the objects the clients can choose
+	// from with the Accept header or the format query param, DefaultFormat
+	// being used when they express no preference.
+	Formats       []string
+	DefaultFormat string
+	// CacheHeaders are the rules setting the caching headers of the responses,
+	// the first one matching the object being applied.
+	CacheHeaders             []CacheHeaderRule
+	PreferObjectCacheControl bool
+	// Download sets the Content-Disposition of the objects sent in
+	// passthrough mode.
+	Download *DownloadOptions
+	// Preload are the keys fetched at startup and served from memory,
+	// refreshed every PreloadInterval when defined, each round of fetches
+	// bounded by the PreloadTimeout.
+	Preload         []string
+	PreloadInterval time.Duration
+	PreloadTimeout  time.Duration
+	// Invalidation is the queue of the event notifications dropping the
+	// modified objects from memory.
+	Invalidation *InvalidationOptions
 }
 
 func BackendFactory(logger logging.Logger, bf proxy.BackendFactory) proxy.BackendFactory {
-	return BackendFactoryWithClient(
-		logger, bf, func(opts *Options) ObjectGetter {
-			return s3.NewFromConfig(opts.AWSConfig)
-		},
-	)
+	return BackendFactoryWithContext(context.Background(), logger, bf)
+}
+
+// BackendFactoryWithContext returns a BackendFactory whose backends refresh
+// their preloaded objects until the given context is done.
+func BackendFactoryWithContext(ctx context.Context, logger logging.Logger, bf proxy.BackendFactory) proxy.BackendFactory {
+	return BackendFactoryWithClientContext(ctx, logger, bf, newClient)
 }
 
 func BackendFactoryWithClient(
 	logger logging.Logger,
 	bf proxy.BackendFactory,
 	clientFactory func(opts *Options) ObjectGetter,
+) proxy.BackendFactory {
+	return BackendFactoryWithClientContext(context.Background(), logger, bf, clientFactory)
+}
+
+// BackendFactoryWithClientContext is BackendFactoryWithContext with a custom
+// client factory.
+func BackendFactoryWithClientContext(
+	ctx context.Context,
+	logger logging.Logger,
+	bf proxy.BackendFactory,
+	clientFactory func(opts *Options) ObjectGetter,
 ) proxy.BackendFactory {
 	return func(remote *config.Backend) proxy.Proxy {
 		logPrefix := "[BACKEND: " + remote.URLPattern + "][S3]"
@@ -56,51 +246,537 @@ func BackendFactoryWithClient(
 			return bf(remote)
 		}
 
-		cl := clientFactory(opts)
-
-		ef := proxy.NewEntityFormatter(remote)
+		var (
+			targets []target
+			tenants map[string][]target
+			primary = []target{}
+		)
+		if len(opts.Tenants) > 0 {
+			tenants = make(map[string][]target, len(opts.Tenants))
+			for name := range opts.Tenants {
+				t := opts.Tenants[name]
+				tenants[name] = []target{newTarget(&t, clientFactory)}
+				primary = append(primary, tenants[name][0])
+			}
+		} else {
+			targets = []target{newTarget(opts, clientFactory)}
+			for i := range opts.Replicas {
+				r := &opts.Replicas[i]
+				targets = append(targets, newTarget(r, clientFactory))
+			}
+			primary = append(primary, targets[0])
+		}
 
-		return func(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
-			k := strings.TrimPrefix(request.Path, "/")
+		for _, t := range primary {
+			if err := checkClient(opts, t.client); err != nil {
+				logger.Error(logPrefix, err)
+				return bf(remote)
+			}
+		}
 
-			if len(opts.PathExtension) > 0 {
-				k += "." + opts.PathExtension
+		// the replicas only serve reads, which may include the tags.
+		for i := 1; i < len(targets); i++ {
+			if _, ok := targets[i].client.(ObjectTagger); opts.includesTags() && !ok {
+				logger.Error(logPrefix, errTaggingUnsupported)
+				return bf(remote)
 			}
+		}
 
-			obj, err := cl.GetObject(
-				ctx, &s3.GetObjectInput{
-					Bucket: &opts.Bucket,
-					Key:    &k,
-				},
-			)
-			if err != nil {
+		b := &backend{
+			logger:    logger,
+			logPrefix: logPrefix,
+			opts:      opts,
+			targets:   targets,
+			tenants:   tenants,
+			cache:     newObjectCache(opts.CacheTTL, opts.ServeStaleOnError),
+			ef:        proxy.NewEntityFormatter(remote),
+			metrics:   newMetrics(remote.URLPattern),
+			tracer:    newTracer(),
+			preloaded: newPreloadStore(opts.Preload),
+			extractor: compileExtract(opts.Extract),
+		}
+		b.preload(ctx)
+		b.subscribe(ctx)
+
+		return b.proxy
+	}
+}
+
+func newClient(opts *Options) ObjectGetter {
+	return s3.NewFromConfig(
+		opts.AWSConfig, func(o *s3.Options) {
+			o.APIOptions = append(o.APIOptions, addTracingMiddleware)
+		},
+	)
+}
+
+// checkClient verifies the client implements the s3 operations required by
+// the options.
+func checkClient(opts *Options, c ObjectGetter) error {
+	if _, ok := c.(ObjectSelector); opts.Select != nil && !ok {
+		return errSelectUnsupported
+	}
+
+	if _, ok := c.(ObjectUploader); opts.Operation == OperationPut && !ok {
+		return errUploadUnsupported
+	}
+
+	if _, ok := c.(ObjectCopier); opts.Copy != nil && !ok {
+		return errCopyUnsupported
+	}
+
+	usesTags := opts.Operation == OperationGetTags || opts.Operation == OperationPutTags || opts.includesTags()
+	if _, ok := c.(ObjectTagger); usesTags && !ok {
+		return errTaggingUnsupported
+	}
+
+	return nil
+}
+
+// object is the content of an s3 object as fetched by the backend.
+type object struct {
+	key             string
+	body            []byte
+	contentType     string
+	contentEncoding string
+	bucket          string
+	tags            map[string]string
+	cacheControl    string
+}
+
+// target is a bucket the backend can read the objects from.
+type target struct {
+	bucket string
+	owner  string
+	config aws.Config
+	client ObjectGetter
+}
+
+func newTarget(opts *Options, clientFactory func(opts *Options) ObjectGetter) target {
+	return target{
+		bucket: opts.Bucket,
+		owner:  opts.ExpectedBucketOwner,
+		config: opts.AWSConfig,
+		client: clientFactory(opts),
+	}
+}
+
+type backend struct {
+	logger    logging.Logger
+	logPrefix string
+	opts      *Options
+	targets   []target
+	tenants   map[string][]target
+	cache     *objectCache
+	preloaded *preloadStore
+	extractor gval.Evaluable
+	ef        proxy.EntityFormatter
+	metrics   *metrics
+	tracer    trace.Tracer
+}
+
+func (b *backend) proxy(ctx context.Context, request *proxy.Request) (response *proxy.Response, err error) {
+	ctx, span := b.tracer.Start(withTraceContext(ctx, request.Headers), "s3.backend")
+	defer func() { endSpan(span, err) }()
+
+	tb, err := b.forTenant(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err = tb.serve(ctx, request)
+	if err != nil {
+		return nil, tb.accessDenied(err)
+	}
+
+	return response, nil
+}
+
+func (b *backend) serve(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	switch b.opts.Operation {
+	case OperationPut:
+		return b.putObject(ctx, request)
+	case OperationPresignPost:
+		return b.presignPost(ctx, request)
+	case OperationCopy, OperationMove:
+		return b.copyObject(ctx, request)
+	case OperationGetTags, OperationPutTags:
+		return b.tags(ctx, request)
+	case OperationBatchGet:
+		return b.batchGet(ctx, request)
+	}
+
+	if b.opts.Select != nil {
+		return b.selectObject(ctx, request)
+	}
+
+	if len(b.opts.Keys) > 0 {
+		return b.merge(ctx, request)
+	}
+
+	var (
+		obj   *object
+		stale bool
+		err   error
+		c     candidate
+	)
+	for _, c = range candidateKeys(b.opts, request) {
+		obj, stale, err = b.fetch(ctx, c.key)
+		if !isNotFound(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := b.newResponse(ctx, obj, c.statusCode, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if stale {
+		response.IsComplete = false
+		response.Metadata.Headers["Warning"] = []string{staleWarning}
+	}
+
+	b.includeTags(response, obj)
+	b.setCacheHeaders(response, obj)
+
+	if len(b.targets) > 1 {
+		response.Metadata.Headers[replicaHeader] = []string{obj.bucket}
+	}
+
+	return response, nil
+}
+
+// newResponse decodes the object into the response data or, in passthrough
+// mode, streams the object as it is stored in the bucket. Compressed objects
+// are forwarded as they are if the client accepts their encoding and
+// ForwardCompressed is enabled, or decompressed otherwise.
+func (b *backend) newResponse(ctx context.Context, obj *object, statusCode int, request *proxy.Request) (*proxy.Response, error) {
+	format, err := b.negotiate(request, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == FormatRaw || (format == "" && b.opts.Passthrough) {
+		headers := map[string][]string{}
+		if obj.contentType != "" {
+			headers["Content-Type"] = []string{obj.contentType}
+		}
+
+		body := obj.body
+		e := obj.encoding()
+		if e != "" && b.opts.ForwardCompressed {
+			// the response depends on the Accept-Encoding, which the caches
+			// in front of the gateway must know about.
+			headers["Vary"] = []string{"Accept-Encoding"}
+		}
+
+		if e != "" && b.opts.ForwardCompressed && acceptsEncoding(request.Headers, e) {
+			headers["Content-Encoding"] = []string{e}
+		} else {
+			if body, err = obj.content(b.opts.MaxDecompressedSize); err != nil {
 				return nil, err
 			}
+		}
 
-			data := map[string]interface{}{}
-			cont, err := io.ReadAll(obj.Body)
-			if err != nil {
-				return nil, err
+		b.setDownloadHeaders(headers, obj, request)
+		b.varyByAccept(headers)
+
+		return &proxy.Response{
+			IsComplete: true,
+			Io:         bytes.NewReader(body),
+			Metadata: proxy.Metadata{
+				Headers:    headers,
+				StatusCode: statusCode,
+			},
+		}, nil
+	}
+
+	data, err := b.decode(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = b.extract(ctx, data, request); err != nil {
+		return nil, err
+	}
+
+	response := b.format(
+		ctx, proxy.Response{
+			Data:       data,
+			IsComplete: true,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: statusCode,
+			},
+		},
+	)
+
+	if err := encode(response, format); err != nil {
+		return nil, err
+	}
+	b.varyByAccept(response.Metadata.Headers)
+
+	return response, nil
+}
+
+// format applies the KrakenD entity formatter options to the response.
+func (b *backend) format(ctx context.Context, response proxy.Response) *proxy.Response {
+	_, span := b.tracer.Start(ctx, "s3.format")
+	defer span.End()
+
+	response = b.ef.Format(response)
+
+	return &response
+}
+
+// decode parses the json content of the object.
+func (b *backend) decode(ctx context.Context, obj *object) (data map[string]interface{}, err error) {
+	_, span := b.tracer.Start(ctx, "s3.decode", trace.WithAttributes(attribute.Int("s3.bytes", len(obj.body))))
+	defer func() { endSpan(span, err) }()
+
+	cont, err := obj.content(b.opts.MaxDecompressedSize)
+	if err != nil {
+		b.metrics.decodeFailed(ctx, obj.bucket)
+		return nil, err
+	}
+
+	data = map[string]interface{}{}
+	if err := json.Unmarshal(cont, &data); err != nil {
+		b.metrics.decodeFailed(ctx, obj.bucket)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// fetch returns the object stored under the given key, reporting whether it
+// is a stale copy served from the cache because s3 could not be reached.
+func (b *backend) fetch(ctx context.Context, k string) (obj *object, stale bool, err error) {
+	ctx, span := b.tracer.Start(ctx, "s3.fetch", trace.WithAttributes(attribute.String("aws.s3.key", k)))
+	defer func() {
+		span.SetAttributes(attribute.Bool("s3.stale", stale))
+		endSpan(span, err)
+	}()
+
+	if err := b.checkAccess(k); err != nil {
+		return nil, false, err
+	}
+
+	bucket := b.targets[0].bucket
+	if obj, ok := b.preloaded.Get(k); ok {
+		b.metrics.cacheLookup(ctx, bucket, cachePreloaded)
+		return obj, false, nil
+	}
+
+	if obj, ok := b.cache.Get(bucket, k); ok {
+		b.metrics.cacheLookup(ctx, bucket, cacheHit)
+		return obj, false, nil
+	}
+
+	if b.cache != nil {
+		b.metrics.cacheLookup(ctx, bucket, cacheMiss)
+	}
+
+	obj, err = b.getObjectWithFailover(ctx, k)
+	if err == nil {
+		b.cache.Set(bucket, k, obj)
+		b.preloaded.Set(k, obj)
+		return obj, false, nil
+	}
+
+	if !isRetryable(err) {
+		return nil, false, err
+	}
+
+	cached, ok := b.cache.GetStale(bucket, k)
+	if !ok {
+		return nil, false, err
+	}
+
+	b.metrics.cacheLookup(ctx, bucket, cacheStale)
+	b.logger.Warning(b.logPrefix, "serving stale object", k, "after error:", err)
+
+	return cached, true, nil
+}
+
+type attempt struct {
+	object *object
+	err    error
+}
+
+// getObjectWithFailover reads the object from the primary bucket, moving to the
+// next replica when it fails or, if a failover timeout is set, when it takes too
+// long. Hedged requests keep the slow attempts running and return the first success.
+func (b *backend) getObjectWithFailover(ctx context.Context, k string) (*object, error) {
+	if len(b.targets) == 1 {
+		return b.getObject(ctx, b.targets[0], k)
+	}
+
+	attemptsCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attempt, len(b.targets))
+	next, pending := 0, 0
+	start := func() {
+		t := b.targets[next]
+		next++
+		pending++
+
+		attemptCtx, attemptCancel := attemptsCtx, context.CancelFunc(func() {})
+		if !b.opts.HedgeRequests && b.opts.FailoverTimeout > 0 && next < len(b.targets) {
+			attemptCtx, attemptCancel = context.WithTimeout(attemptsCtx, b.opts.FailoverTimeout)
+		}
+
+		go func() {
+			defer attemptCancel()
+			obj, err := b.getObject(attemptCtx, t, k)
+			results <- attempt{object: obj, err: err}
+		}()
+	}
+	start()
+
+	var err error
+	for pending > 0 {
+		var hedge *time.Timer
+		var hedgeC <-chan time.Time
+		if b.opts.HedgeRequests && b.opts.FailoverTimeout > 0 && next < len(b.targets) {
+			hedge = time.NewTimer(b.opts.FailoverTimeout)
+			hedgeC = hedge.C
+		}
+
+		select {
+		case <-hedgeC:
+			start()
+		case a := <-results:
+			pending--
+			if a.err == nil {
+				return a.object, nil
 			}
 
-			if err := json.Unmarshal(cont, &data); err != nil {
+			err = a.err
+			if isNotFound(err) || ctx.Err() != nil {
 				return nil, err
 			}
 
-			response := proxy.Response{
-				Data:       data,
-				IsComplete: true,
-				Metadata: proxy.Metadata{
-					Headers:    map[string][]string{},
-					StatusCode: 200,
-				},
+			if next < len(b.targets) {
+				start()
 			}
+		}
+
+		if hedge != nil {
+			hedge.Stop()
+		}
+	}
+
+	return nil, err
+}
+
+func (b *backend) getObject(ctx context.Context, t target, k string) (obj *object, err error) {
+	ctx, span := b.tracer.Start(
+		ctx, "s3.GetObject",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("aws.s3.bucket", t.bucket), attribute.String("aws.s3.key", k)),
+	)
+	start := time.Now()
+	metadata := middleware.Metadata{}
+	defer func() {
+		n := 0
+		if obj != nil {
+			n = len(obj.body)
+		}
+		b.metrics.observe(ctx, "GetObject", t.bucket, start, n, err)
+		b.logOperation("GetObject", t.bucket, k, start, metadata, err)
+		span.SetAttributes(attribute.Int("s3.bytes", n))
+		endSpan(span, err)
+	}()
+
+	in := &s3.GetObjectInput{
+		Bucket: &t.bucket,
+		Key:    &k,
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+	}
+	if b.opts.RequestPayer {
+		in.RequestPayer = types.RequestPayerRequester
+	}
+	if b.opts.ValidateChecksum {
+		in.ChecksumMode = types.ChecksumModeEnabled
+	}
+
+	out, err := t.client.GetObject(ctx, in)
+	if err != nil {
+		span.SetAttributes(operationAttributes(metadata, err)...)
+		return nil, err
+	}
+
+	metadata = out.ResultMetadata
+	span.SetAttributes(operationAttributes(metadata, nil)...)
+	if out.VersionId != nil {
+		span.SetAttributes(attribute.String("aws.s3.version_id", *out.VersionId))
+	}
+	defer out.Body.Close()
 
-			response = ef.Format(response)
+	cont, err := io.ReadAll(out.Body)
+	if err != nil && b.opts.ValidateChecksum {
+		return nil, readError(err)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-			return &response, nil
+	if b.opts.ValidateChecksum {
+		if err := verifyChecksum(out, cont); err != nil {
+			return nil, err
 		}
 	}
+
+	obj = &object{
+		key:             k,
+		body:            cont,
+		contentType:     aws.ToString(out.ContentType),
+		contentEncoding: aws.ToString(out.ContentEncoding),
+		cacheControl:    aws.ToString(out.CacheControl),
+		bucket:          t.bucket,
+	}
+
+	if b.opts.includesTags() && out.TagCount > 0 {
+		tagger, ok := t.client.(ObjectTagger)
+		if !ok {
+			return nil, errTaggingUnsupported
+		}
+
+		if obj.tags, _, err = b.getTags(ctx, tagger, t, k); err != nil {
+			return nil, err
+		}
+	}
+
+	return obj, nil
+}
+
+// isNotFound reports whether the error means the requested key does not exist in the bucket.
+func isNotFound(err error) bool {
+	var nsk *types.NoSuchKey
+	return errors.As(err, &nsk)
+}
+
+// isRetryable reports whether the error is a transient failure, like a 5xx
+// response or a timeout, rather than a definitive answer from s3.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary
 }
 
 func getOptions(remote *config.Backend) (*Options, error) {
@@ -114,17 +790,13 @@ func getOptions(remote *config.Backend) (*Options, error) {
 		return nil, errInvalidConfig
 	}
 
-	v, ok = cfg["bucket"]
-	if !ok {
-		return nil, errInvalidBucket
-	}
-
-	bucket, ok := v.(string)
-	if !ok {
-		return nil, errInvalidBucket
-	}
-
-	if bucket == "" {
+	var bucket string
+	if v, ok = cfg["bucket"]; ok {
+		bucket, ok = v.(string)
+		if !ok || bucket == "" {
+			return nil, errInvalidBucket
+		}
+	} else if _, ok := cfg["tenants"]; !ok {
 		return nil, errInvalidBucket
 	}
 
@@ -138,15 +810,15 @@ func getOptions(remote *config.Backend) (*Options, error) {
 	}
 
 	if endpoint, ok := cfg["endpoint"].(string); ok && endpoint != "" {
-		opts.AWSConfig.EndpointResolverWithOptions = aws.EndpointResolverWithOptionsFunc(
-			func(service, r string, options ...interface{}) (aws.Endpoint, error) {
-				return aws.Endpoint{
-					URL:               endpoint,
-					SigningRegion:     r,
-					HostnameImmutable: true,
-				}, nil
-			},
-		)
+		opts.AWSConfig.EndpointResolverWithOptions = endpointResolver(endpoint)
+	}
+
+	credentials, err := getCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if credentials != nil {
+		opts.AWSConfig.Credentials = credentials
 	}
 
 	if maxRetries, ok := cfg["max_retries"].(int); ok {
@@ -157,5 +829,320 @@ func getOptions(remote *config.Backend) (*Options, error) {
 		opts.PathExtension = strings.TrimPrefix(pathExtension, ".")
 	}
 
+	if opts.CacheTTL, err = getDuration(cfg, "cache_ttl"); err != nil {
+		return nil, errInvalidCache
+	}
+
+	if opts.ServeStaleOnError, err = getDuration(cfg, "serve_stale_on_error"); err != nil {
+		return nil, errInvalidCache
+	}
+
+	if opts.FallbackKeys, err = getFallbackKeys(cfg); err != nil {
+		return nil, err
+	}
+
+	if statusCode, ok := getInt(cfg, "fallback_status_code"); ok {
+		opts.FallbackStatusCode = statusCode
+	}
+
+	if indexDocument, ok := cfg["index_document"].(string); ok {
+		opts.IndexDocument = strings.TrimPrefix(indexDocument, "/")
+	}
+
+	if tryHTML, ok := cfg["try_html_extension"].(bool); ok {
+		opts.TryHTMLExtension = tryHTML
+	}
+
+	if errorDocument, ok := cfg["error_document"].(string); ok {
+		opts.ErrorDocument = strings.TrimPrefix(errorDocument, "/")
+	}
+
+	opts.Passthrough = remote.Encoding == encoding.NOOP
+
+	if requestPayer, ok := cfg["requester_pays"].(bool); ok {
+		opts.RequestPayer = requestPayer
+	}
+
+	if opts.ExpectedBucketOwner, err = getBucketOwner(cfg); err != nil {
+		return nil, err
+	}
+
+	if validateChecksum, ok := cfg["validate_checksum"].(bool); ok {
+		opts.ValidateChecksum = validateChecksum
+	}
+
+	if err := getOperation(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Replicas, err = getReplicas(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.FailoverTimeout, err = getDuration(cfg, "failover_timeout"); err != nil {
+		return nil, errInvalidReplicas
+	}
+
+	if hedge, ok := cfg["hedge_requests"].(bool); ok {
+		opts.HedgeRequests = hedge
+	}
+
+	if opts.Keys, err = getObjectKeys(cfg); err != nil {
+		return nil, err
+	}
+
+	if len(opts.Keys) > 0 {
+		opts.MergeStrategy = MergeOverlay
+	}
+
+	if strategy, ok := cfg["merge_strategy"].(string); ok {
+		if strategy != MergeOverlay && strategy != MergeNamespace {
+			return nil, errInvalidKeys
+		}
+		opts.MergeStrategy = strategy
+	}
+
+	if skipMissing, ok := cfg["skip_missing"].(bool); ok {
+		opts.SkipMissing = skipMissing
+	}
+
+	if forwardCompressed, ok := cfg["forward_compressed"].(bool); ok {
+		opts.ForwardCompressed = forwardCompressed
+	}
+
+	if opts.MaxDecompressedSize, err = getMaxDecompressedSize(cfg); err != nil {
+		return nil, err
+	}
+
+	if opts.Select, err = getSelectOptions(cfg); err != nil {
+		return nil, err
+	}
+
+	if extract, ok := cfg["extract"].(string); ok && extract != "" {
+		if err := validateExtract(extract); err != nil {
+			return nil, err
+		}
+		opts.Extract = extract
+	}
+
+	if extractFirst, ok := cfg["extract_first"].(bool); ok {
+		opts.ExtractFirst = extractFirst
+	}
+
+	if err := getLogOptions(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.AccessRules, err = getAccessRules(cfg); err != nil {
+		return nil, err
+	}
+
+	if opts.Tenant, opts.Tenants, err = getTenants(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.PostPolicy, err = getPostPolicy(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Copy, err = getCopyOptions(cfg, opts.Operation); err != nil {
+		return nil, err
+	}
+
+	if err := getTagsOptions(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if err := getBatchOptions(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if err := getFormats(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if err := getCacheHeaders(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Download, err = getDownloadOptions(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := getPreload(cfg, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Invalidation, err = getInvalidation(cfg, opts); err != nil {
+		return nil, err
+	}
+
 	return opts, nil
 }
+
+// getReplicas parses the replica buckets, which inherit the primary aws config
+// and expected bucket owner unless they define their own.
+func getReplicas(cfg map[string]interface{}, primary *Options) ([]Options, error) {
+	v, ok := cfg["replicas"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, errInvalidReplicas
+	}
+
+	replicas := make([]Options, 0, len(vs))
+	for _, v := range vs {
+		rc, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errInvalidReplicas
+		}
+
+		bucket, ok := rc["bucket"].(string)
+		if !ok || bucket == "" {
+			return nil, errInvalidReplicas
+		}
+
+		r := Options{
+			Bucket:              bucket,
+			AWSConfig:           primary.AWSConfig.Copy(),
+			ExpectedBucketOwner: primary.ExpectedBucketOwner,
+		}
+
+		if rc["expected_bucket_owner"] != nil {
+			owner, err := getBucketOwner(rc)
+			if err != nil {
+				return nil, err
+			}
+			r.ExpectedBucketOwner = owner
+		}
+
+		if region, ok := rc["region"].(string); ok && region != "" {
+			r.AWSConfig.Region = region
+		}
+
+		if endpoint, ok := rc["endpoint"].(string); ok && endpoint != "" {
+			r.AWSConfig.EndpointResolverWithOptions = endpointResolver(endpoint)
+		}
+
+		replicas = append(replicas, r)
+	}
+
+	return replicas, nil
+}
+
+// getCredentials returns a provider of the static credentials defined by the
+// "key" and "secret" options, or nil when none is defined.
+func getCredentials(cfg map[string]interface{}) (aws.CredentialsProvider, error) {
+	key, _ := cfg["key"].(string)
+	secret, _ := cfg["secret"].(string)
+	if (key == "") != (secret == "") {
+		return nil, errInvalidCredentials
+	}
+
+	if key == "" {
+		return nil, nil
+	}
+
+	return aws.NewCredentialsCache(
+		aws.CredentialsProviderFunc(
+			func(_ context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: key, SecretAccessKey: secret, Source: "krakend-s3"}, nil
+			},
+		),
+	), nil
+}
+
+func endpointResolver(endpoint string) aws.EndpointResolverWithOptions {
+	return aws.EndpointResolverWithOptionsFunc(
+		func(service, r string, options ...interface{}) (aws.Endpoint, error) {
+			return aws.Endpoint{
+				URL:               endpoint,
+				SigningRegion:     r,
+				HostnameImmutable: true,
+			}, nil
+		},
+	)
+}
+
+func getFallbackKeys(cfg map[string]interface{}) ([]string, error) {
+	if v, ok := cfg["fallback_key"]; ok && v != nil {
+		k, ok := v.(string)
+		if !ok || k == "" {
+			return nil, errInvalidFallbackKeys
+		}
+
+		return []string{k}, nil
+	}
+
+	v, ok := cfg["fallback_keys"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	keys, err := getStrings(v)
+	if err != nil {
+		return nil, errInvalidFallbackKeys
+	}
+
+	return keys, nil
+}
+
+// getStrings converts a list of non empty strings from the config.
+func getStrings(v interface{}) ([]string, error) {
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, errInvalidConfig
+	}
+
+	res := make([]string, 0, len(vs))
+	for _, v := range vs {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return nil, errInvalidConfig
+		}
+		res = append(res, s)
+	}
+
+	return res, nil
+}
+
+// getInt returns the integer stored under the given name, accepting the float64
+// values produced when the config is parsed from json.
+func getInt(cfg map[string]interface{}, name string) (int, bool) {
+	switch v := cfg[name].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+
+	return 0, false
+}
+
+// getDuration parses the duration string stored under the given name, returning
+// zero when it is not defined.
+func getDuration(cfg map[string]interface{}, name string) (time.Duration, error) {
+	v, ok := cfg[name]
+	if !ok || v == nil {
+		return 0, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return 0, errInvalidConfig
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if d < 0 {
+		return 0, errInvalidConfig
+	}
+
+	return d, nil
+}
@@ -5,17 +5,23 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/golang/mock/gomock"
 	s3 "github.com/jbactad/krakend-s3"
 	"github.com/jbactad/krakend-s3/mocks"
 	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/encoding"
 	"github.com/luraproject/lura/v2/logging"
 	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -69,7 +75,7 @@ func TestBackendFactoryWithClient_backendProxyInvoked(t *testing.T) {
 				k := "sample"
 				client.EXPECT().
 					GetObject(
-						ctx, gomock.Eq(
+						gomock.Any(), gomock.Eq(
 							&awsS3.GetObjectInput{
 								Bucket: &b,
 								Key:    &k,
@@ -119,7 +125,7 @@ func TestBackendFactoryWithClient_backendProxyInvoked(t *testing.T) {
 				k := "sample.json"
 				client.EXPECT().
 					GetObject(
-						ctx, gomock.Eq(
+						gomock.Any(), gomock.Eq(
 							&awsS3.GetObjectInput{
 								Bucket: &b,
 								Key:    &k,
@@ -258,6 +264,607 @@ func TestBackendFactoryWithClient_backendProxyInvoked(t *testing.T) {
 	}
 }
 
+func TestBackendFactoryWithClient_serveStaleOnError(t *testing.T) {
+	unavailable := &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+		Err:      errors.New("service unavailable"),
+	}
+
+	type args struct {
+		config map[string]interface{}
+		err    error
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setup   func(logger *mocks.MockLogger)
+		wantErr assert.ErrorAssertionFunc
+		want    *proxy.Response
+	}{
+		{
+			name: "s3 unavailable, should serve stale copy",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":               "bucket1",
+					"serve_stale_on_error": "1h",
+				},
+				err: unavailable,
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Warning("[BACKEND: /sample][S3]", "serving stale object", "sample", "after error:", unavailable)
+			},
+			wantErr: assert.NoError,
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"property1": "value1",
+				},
+				IsComplete: false,
+				Metadata: proxy.Metadata{
+					Headers:    map[string][]string{"Warning": {`110 - "Response is Stale"`}},
+					StatusCode: 200,
+				},
+			},
+		},
+		{
+			name: "s3 timed out, should serve stale copy",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":               "bucket1",
+					"serve_stale_on_error": "1h",
+				},
+				err: context.DeadlineExceeded,
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Warning("[BACKEND: /sample][S3]", "serving stale object", "sample", "after error:", context.DeadlineExceeded)
+			},
+			wantErr: assert.NoError,
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"property1": "value1",
+				},
+				IsComplete: false,
+				Metadata: proxy.Metadata{
+					Headers:    map[string][]string{"Warning": {`110 - "Response is Stale"`}},
+					StatusCode: 200,
+				},
+			},
+		},
+		{
+			name: "s3 returned a non retryable error, should return error",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":               "bucket1",
+					"serve_stale_on_error": "1h",
+				},
+				err: &types.NoSuchKey{},
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.IsType(t, &types.NoSuchKey{}, err, i...)
+			},
+		},
+		{
+			name: "serve_stale_on_error not configured, should return error",
+			args: args{
+				config: map[string]interface{}{
+					"bucket": "bucket1",
+				},
+				err: unavailable,
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, unavailable, err, i...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				l := mocks.NewMockLogger(ctrl)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				ctx := context.Background()
+
+				if tt.setup != nil {
+					tt.setup(l)
+				}
+
+				gomock.InOrder(
+					cl.EXPECT().
+						GetObject(gomock.Any(), gomock.Any()).
+						Return(
+							&awsS3.GetObjectOutput{
+								Body: io.NopCloser(strings.NewReader(`{"property1": "value1"}`)),
+							}, nil,
+						),
+					cl.EXPECT().
+						GetObject(gomock.Any(), gomock.Any()).
+						Return(nil, tt.args.err),
+				)
+
+				b := s3.BackendFactoryWithClient(
+					l, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{URLPattern: "/sample", ExtraConfig: map[string]interface{}{s3.Namespace: tt.args.config}})
+
+				_, err := p(ctx, &proxy.Request{Path: "/sample"})
+				assert.NoError(t, err)
+
+				got, err := p(ctx, &proxy.Request{Path: "/sample"})
+				if !tt.wantErr(t, err) {
+					return
+				}
+
+				assert.EqualValues(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_cacheTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+	ctx := context.Background()
+
+	cl.EXPECT().
+		GetObject(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(
+			&awsS3.GetObjectOutput{
+				Body: io.NopCloser(strings.NewReader(`{"property1": "value1"}`)),
+			}, nil,
+		)
+
+	b := s3.BackendFactoryWithClient(
+		logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":    "bucket1",
+					"cache_ttl": "1h",
+				},
+			},
+		},
+	)
+
+	for i := 0; i < 2; i++ {
+		got, err := p(ctx, &proxy.Request{Path: "/sample"})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"property1": "value1"}, got.Data)
+		assert.True(t, got.IsComplete)
+	}
+}
+
+func TestBackendFactoryWithClient_fallbackKeys(t *testing.T) {
+	type args struct {
+		config map[string]interface{}
+		path   string
+	}
+	tests := []struct {
+		name     string
+		args     args
+		setup    func(client *mocks.MockObjectGetter)
+		wantErr  assert.ErrorAssertionFunc
+		wantData map[string]interface{}
+		wantCode int
+	}{
+		{
+			name: "requested key found, should not try the fallback keys",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":        "bucket1",
+					"fallback_keys": []interface{}{"{path}/index.json", "defaults.json"},
+				},
+				path: "/sample",
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "sample").Return(objectOutput(`{"key": "sample"}`), nil)
+			},
+			wantErr:  assert.NoError,
+			wantData: map[string]interface{}{"key": "sample"},
+			wantCode: 200,
+		},
+		{
+			name: "requested key missing, should try the fallback keys in order",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":               "bucket1",
+					"fallback_keys":        []interface{}{"{path}/index.json", "defaults.json"},
+					"fallback_status_code": float64(404),
+				},
+				path: "/sample",
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				gomock.InOrder(
+					expectGetObject(client, "sample").Return(nil, &types.NoSuchKey{}),
+					expectGetObject(client, "sample/index.json").Return(nil, &types.NoSuchKey{}),
+					expectGetObject(client, "defaults.json").Return(objectOutput(`{"key": "defaults"}`), nil),
+				)
+			},
+			wantErr:  assert.NoError,
+			wantData: map[string]interface{}{"key": "defaults"},
+			wantCode: 404,
+		},
+		{
+			name: "single fallback_key, should fetch it when the requested key is missing",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":         "bucket1",
+					"path_extension": "json",
+					"fallback_key":   "defaults.json",
+				},
+				path: "/sample",
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				gomock.InOrder(
+					expectGetObject(client, "sample.json").Return(nil, &types.NoSuchKey{}),
+					expectGetObject(client, "defaults.json").Return(objectOutput(`{"key": "defaults"}`), nil),
+				)
+			},
+			wantErr:  assert.NoError,
+			wantData: map[string]interface{}{"key": "defaults"},
+			wantCode: 200,
+		},
+		{
+			name: "all keys missing, should return the not found error",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":       "bucket1",
+					"fallback_key": "defaults.json",
+				},
+				path: "/sample",
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				gomock.InOrder(
+					expectGetObject(client, "sample").Return(nil, &types.NoSuchKey{}),
+					expectGetObject(client, "defaults.json").Return(nil, &types.NoSuchKey{}),
+				)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.IsType(t, &types.NoSuchKey{}, err, i...)
+			},
+		},
+		{
+			name: "requested key failed with another error, should not try the fallback keys",
+			args: args{
+				config: map[string]interface{}{
+					"bucket":       "bucket1",
+					"fallback_key": "defaults.json",
+				},
+				path: "/sample",
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "sample").Return(nil, errors.New("something went wrong"))
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.EqualError(t, err, "something went wrong", i...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				tt.setup(cl)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: tt.args.config}})
+
+				got, err := p(context.Background(), &proxy.Request{Path: tt.args.path})
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				assert.Equal(t, tt.wantData, got.Data)
+				assert.Equal(t, tt.wantCode, got.Metadata.StatusCode)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_website(t *testing.T) {
+	website := map[string]interface{}{
+		"bucket":             "bucket1",
+		"index_document":     "index.html",
+		"try_html_extension": true,
+		"error_document":     "404.html",
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		setup    func(client *mocks.MockObjectGetter)
+		wantErr  assert.ErrorAssertionFunc
+		wantBody string
+		wantCode int
+	}{
+		{
+			name: "trailing slash, should serve the index document",
+			path: "/docs/",
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "docs/index.html").Return(htmlOutput("docs"), nil)
+			},
+			wantErr:  assert.NoError,
+			wantBody: "docs",
+			wantCode: 200,
+		},
+		{
+			name: "root path, should serve the index document",
+			path: "/",
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "index.html").Return(htmlOutput("home"), nil)
+			},
+			wantErr:  assert.NoError,
+			wantBody: "home",
+			wantCode: 200,
+		},
+		{
+			name: "key without extension missing, should try the html extension",
+			path: "/about",
+			setup: func(client *mocks.MockObjectGetter) {
+				gomock.InOrder(
+					expectGetObject(client, "about").Return(nil, &types.NoSuchKey{}),
+					expectGetObject(client, "about.html").Return(htmlOutput("about"), nil),
+				)
+			},
+			wantErr:  assert.NoError,
+			wantBody: "about",
+			wantCode: 200,
+		},
+		{
+			name: "key with extension missing, should serve the error document",
+			path: "/style.css",
+			setup: func(client *mocks.MockObjectGetter) {
+				gomock.InOrder(
+					expectGetObject(client, "style.css").Return(nil, &types.NoSuchKey{}),
+					expectGetObject(client, "404.html").Return(htmlOutput("not found"), nil),
+				)
+			},
+			wantErr:  assert.NoError,
+			wantBody: "not found",
+			wantCode: 404,
+		},
+		{
+			name: "error document missing, should return the not found error",
+			path: "/style.css",
+			setup: func(client *mocks.MockObjectGetter) {
+				gomock.InOrder(
+					expectGetObject(client, "style.css").Return(nil, &types.NoSuchKey{}),
+					expectGetObject(client, "404.html").Return(nil, &types.NoSuchKey{}),
+				)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.IsType(t, &types.NoSuchKey{}, err, i...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				tt.setup(cl)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						Encoding:    encoding.NOOP,
+						ExtraConfig: map[string]interface{}{s3.Namespace: website},
+					},
+				)
+
+				got, err := p(context.Background(), &proxy.Request{Path: tt.path})
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				body, err := io.ReadAll(got.Io)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBody, string(body))
+				assert.Equal(t, tt.wantCode, got.Metadata.StatusCode)
+				assert.Equal(t, []string{"text/html"}, got.Metadata.Headers["Content-Type"])
+				assert.Nil(t, got.Data)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_replicas(t *testing.T) {
+	slow := func(d time.Duration, out *awsS3.GetObjectOutput) func(context.Context, *awsS3.GetObjectInput, ...func(*awsS3.Options)) (*awsS3.GetObjectOutput, error) {
+		return func(ctx context.Context, _ *awsS3.GetObjectInput, _ ...func(*awsS3.Options)) (*awsS3.GetObjectOutput, error) {
+			select {
+			case <-time.After(d):
+				return out, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	tests := []struct {
+		name       string
+		config     map[string]interface{}
+		setup      func(primary, replica *mocks.MockObjectGetter)
+		wantErr    assert.ErrorAssertionFunc
+		wantData   map[string]interface{}
+		wantBucket string
+	}{
+		{
+			name: "primary succeeded, should not read from the replica",
+			config: map[string]interface{}{
+				"bucket":   "bucket1",
+				"replicas": []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+			setup: func(primary, replica *mocks.MockObjectGetter) {
+				expectGetObject(primary, "sample").Return(objectOutput(`{"from": "primary"}`), nil)
+			},
+			wantErr:    assert.NoError,
+			wantData:   map[string]interface{}{"from": "primary"},
+			wantBucket: "bucket1",
+		},
+		{
+			name: "primary failed, should fail over to the replica",
+			config: map[string]interface{}{
+				"bucket":   "bucket1",
+				"replicas": []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+			setup: func(primary, replica *mocks.MockObjectGetter) {
+				expectGetObject(primary, "sample").Return(nil, errors.New("something went wrong"))
+				replica.EXPECT().
+					GetObject(gomock.Any(), gomock.Eq(&awsS3.GetObjectInput{Bucket: aws.String("bucket2"), Key: aws.String("sample")})).
+					Return(objectOutput(`{"from": "replica"}`), nil)
+			},
+			wantErr:    assert.NoError,
+			wantData:   map[string]interface{}{"from": "replica"},
+			wantBucket: "bucket2",
+		},
+		{
+			name: "primary returned not found, should not fail over",
+			config: map[string]interface{}{
+				"bucket":   "bucket1",
+				"replicas": []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+			setup: func(primary, replica *mocks.MockObjectGetter) {
+				expectGetObject(primary, "sample").Return(nil, &types.NoSuchKey{})
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.IsType(t, &types.NoSuchKey{}, err, i...)
+			},
+		},
+		{
+			name: "all buckets failed, should return the last error",
+			config: map[string]interface{}{
+				"bucket":   "bucket1",
+				"replicas": []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+			setup: func(primary, replica *mocks.MockObjectGetter) {
+				expectGetObject(primary, "sample").Return(nil, errors.New("primary went wrong"))
+				replica.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(nil, errors.New("replica went wrong"))
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.EqualError(t, err, "replica went wrong", i...)
+			},
+		},
+		{
+			name: "primary slower than failover_timeout, should cancel it and read from the replica",
+			config: map[string]interface{}{
+				"bucket":           "bucket1",
+				"failover_timeout": "10ms",
+				"replicas":         []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+			setup: func(primary, replica *mocks.MockObjectGetter) {
+				primary.EXPECT().GetObject(gomock.Any(), gomock.Any()).
+					DoAndReturn(slow(time.Minute, objectOutput(`{"from": "primary"}`)))
+				replica.EXPECT().GetObject(gomock.Any(), gomock.Any()).Return(objectOutput(`{"from": "replica"}`), nil)
+			},
+			wantErr:    assert.NoError,
+			wantData:   map[string]interface{}{"from": "replica"},
+			wantBucket: "bucket2",
+		},
+		{
+			name: "hedged requests, should return the first success",
+			config: map[string]interface{}{
+				"bucket":           "bucket1",
+				"failover_timeout": "10ms",
+				"hedge_requests":   true,
+				"replicas":         []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+			setup: func(primary, replica *mocks.MockObjectGetter) {
+				primary.EXPECT().GetObject(gomock.Any(), gomock.Any()).
+					DoAndReturn(slow(time.Minute, objectOutput(`{"from": "primary"}`)))
+				replica.EXPECT().GetObject(gomock.Any(), gomock.Any()).
+					DoAndReturn(slow(0, objectOutput(`{"from": "replica"}`)))
+			},
+			wantErr:    assert.NoError,
+			wantData:   map[string]interface{}{"from": "replica"},
+			wantBucket: "bucket2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				primary := mocks.NewMockObjectGetter(ctrl)
+				replica := mocks.NewMockObjectGetter(ctrl)
+				tt.setup(primary, replica)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						if opts.Bucket == "bucket2" {
+							return replica
+						}
+						return primary
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: tt.config}})
+
+				got, err := p(context.Background(), &proxy.Request{Path: "/sample"})
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				assert.Equal(t, tt.wantData, got.Data)
+				assert.Equal(t, []string{tt.wantBucket}, got.Metadata.Headers["X-S3-Replica"])
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_replicaOptions(t *testing.T) {
+	var got []*s3.Options
+	b := s3.BackendFactoryWithClient(
+		logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			got = append(got, opts)
+			return nil
+		},
+	)
+	b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":      "bucket1",
+					"region":      "eu-west-1",
+					"max_retries": 3,
+					"replicas": []interface{}{
+						map[string]interface{}{"bucket": "bucket2", "region": "us-east-1"},
+						map[string]interface{}{"bucket": "bucket3"},
+					},
+				},
+			},
+		},
+	)
+
+	if !assert.Len(t, got, 3) {
+		return
+	}
+
+	assert.Equal(t, "bucket1", got[0].Bucket)
+	assert.Equal(t, "bucket2", got[1].Bucket)
+	assert.Equal(t, "us-east-1", got[1].AWSConfig.Region)
+	assert.Equal(t, 3, got[1].AWSConfig.RetryMaxAttempts)
+	assert.Equal(t, "bucket3", got[2].Bucket)
+	assert.Equal(t, "eu-west-1", got[2].AWSConfig.Region)
+}
+
 func TestBackendFactoryWithClient_validConfig(t *testing.T) {
 	l := logging.NoOp
 	type args struct {
@@ -597,6 +1204,29 @@ func TestBackendFactoryWithClient_validConfig(t *testing.T) {
 				)
 			},
 		},
+		{
+			name: "with cache_ttl and serve_stale_on_error",
+			args: args{
+				config: &config.Backend{
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":               "bucket1",
+							"cache_ttl":            "30s",
+							"serve_stale_on_error": "10m",
+						},
+					},
+				},
+			},
+			want: func(t assert.TestingT, i interface{}, i2 ...interface{}) bool {
+				return assert.EqualValues(
+					t, &s3.Options{
+						Bucket:            "bucket1",
+						CacheTTL:          30 * time.Second,
+						ServeStaleOnError: 10 * time.Minute,
+					}, i, i2...,
+				)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -736,6 +1366,227 @@ func TestBackendFactory_invalidConfig(t *testing.T) {
 				)
 			},
 		},
+		{
+			name: "invalid serve_stale_on_error, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":               "bucket1",
+							"serve_stale_on_error": "10 minutes",
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "cache_ttl" or "serve_stale_on_error" defined`),
+				)
+			},
+		},
+		{
+			name: "key without secret, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket": "bucket1",
+							"key":    "some-key",
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "key" or "secret" defined`),
+				)
+			},
+		},
+		{
+			name: "invalid log_sampling, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":       "bucket1",
+							"log_level":    "debug",
+							"log_sampling": 2.0,
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "log_level" or "log_sampling" defined`),
+				)
+			},
+		},
+		{
+			name: "invalid default_format, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":         "bucket1",
+							"formats":        []interface{}{"json", "yaml"},
+							"default_format": "raw",
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "formats" or "default_format" defined`),
+				)
+			},
+		},
+		{
+			name: "empty cache_headers rule, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":        "bucket1",
+							"cache_headers": []interface{}{map[string]interface{}{"pattern": "*.json"}},
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "cache_headers" or "prefer_object_cache_control" defined`),
+				)
+			},
+		},
+		{
+			name: "preload key with placeholders, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":  "bucket1",
+							"preload": []interface{}{"flags/{env}.json"},
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "preload", "preload_interval" or "preload_timeout" defined`),
+				)
+			},
+		},
+		{
+			name: "invalidation wait_time over 20s, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":    "bucket1",
+							"key":       "key",
+							"secret":    "secret",
+							"cache_ttl": "1m",
+							"invalidation": map[string]interface{}{
+								"queue_url": "https://sqs.us-east-1.amazonaws.com/123456789012/events",
+								"wait_time": "30s",
+							},
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "invalidation" defined`),
+				)
+			},
+		},
+		{
+			name: "invalidation wait_time of 0s, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":    "bucket1",
+							"key":       "key",
+							"secret":    "secret",
+							"cache_ttl": "1m",
+							"invalidation": map[string]interface{}{
+								"queue_url": "https://sqs.us-east-1.amazonaws.com/123456789012/events",
+								"wait_time": "0s",
+							},
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "invalidation" defined`),
+				)
+			},
+		},
+		{
+			name: "invalidation without credentials, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket":    "bucket1",
+							"cache_ttl": "1m",
+							"invalidation": map[string]interface{}{
+								"queue_url": "https://sqs.us-east-1.amazonaws.com/123456789012/events",
+							},
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "invalidation" defined`),
+				)
+			},
+		},
+		{
+			name: "invalidation without cache, should log error and return original proxy",
+			args: args{
+				config: &config.Backend{
+					URLPattern: "/some-endpoint",
+					ExtraConfig: map[string]interface{}{
+						s3.Namespace: map[string]interface{}{
+							"bucket": "bucket1",
+							"key":    "key",
+							"secret": "secret",
+							"invalidation": map[string]interface{}{
+								"queue_url": "https://sqs.us-east-1.amazonaws.com/123456789012/events",
+							},
+						},
+					},
+				},
+			},
+			setup: func(logger *mocks.MockLogger) {
+				logger.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "invalidation" defined`),
+				)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(
@@ -760,6 +1611,46 @@ func TestBackendFactory_invalidConfig(t *testing.T) {
 	}
 }
 
+func expectGetObject(client *mocks.MockObjectGetter, key string) *gomock.Call {
+	return client.EXPECT().GetObject(
+		gomock.Any(), gomock.Eq(
+			&awsS3.GetObjectInput{
+				Bucket: aws.String("bucket1"),
+				Key:    aws.String(key),
+			},
+		),
+	)
+}
+
+func objectOutput(body string) *awsS3.GetObjectOutput {
+	return &awsS3.GetObjectOutput{
+		Body: io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func htmlOutput(body string) *awsS3.GetObjectOutput {
+	return &awsS3.GetObjectOutput{
+		Body:        io.NopCloser(strings.NewReader(body)),
+		ContentType: aws.String("text/html"),
+	}
+}
+
+// wantStatusCode asserts the error carries the given http status code.
+func wantStatusCode(code int) assert.ErrorAssertionFunc {
+	return func(t assert.TestingT, err error, i ...interface{}) bool {
+		var respErr client.HTTPResponseError
+		if !assert.ErrorAs(t, err, &respErr, i...) {
+			return false
+		}
+
+		return assert.Equal(t, code, respErr.StatusCode(), i...)
+	}
+}
+
+func noopBackendFactory(_ *config.Backend) proxy.Proxy {
+	return proxy.NoopProxy
+}
+
 type faultyReader struct {
 	error error
 }
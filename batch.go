@@ -0,0 +1,168 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+const (
+	defaultMaxBatchSize     = 100
+	defaultBatchConcurrency = 10
+	batchKeyParam           = "key"
+)
+
+var (
+	errInvalidBatch     = errors.New(`aws s3: invalid "max_batch_size" or "batch_concurrency" defined`)
+	errNoBatchKeys      = client.HTTPResponseError{Code: http.StatusBadRequest, Msg: "aws s3: the request has no keys to fetch"}
+	errBatchTooLarge    = client.HTTPResponseError{Code: http.StatusRequestEntityTooLarge, Msg: "aws s3: too many keys requested"}
+	errInvalidBatchBody = client.HTTPResponseError{Code: http.StatusBadRequest, Msg: `aws s3: the request body must be an object with a "keys" list`}
+)
+
+// batchGet fetches the keys listed in the request concurrently, with at most
+// BatchConcurrency requests to s3 at a time, and returns the decoded content
+// or the error of each key, indexed by key.
+func (b *backend) batchGet(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	keys, err := batchKeys(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > b.opts.MaxBatchSize {
+		return nil, errBatchTooLarge
+	}
+
+	results := make([]map[string]interface{}, len(keys))
+	work := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < b.opts.BatchConcurrency && w < len(keys); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = b.batchResult(ctx, keys[i])
+			}
+		}()
+	}
+	for i := range keys {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	data := make(map[string]interface{}, len(keys))
+	for i, k := range keys {
+		data[k] = results[i]
+	}
+
+	return b.format(
+		ctx, proxy.Response{
+			Data:       data,
+			IsComplete: true,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: http.StatusOK,
+			},
+		},
+	), nil
+}
+
+// batchResult fetches and decodes a single key of a batch, reporting its
+// status code along with its content or error.
+func (b *backend) batchResult(ctx context.Context, k string) map[string]interface{} {
+	obj, _, err := b.fetch(ctx, k)
+	if err == nil {
+		var data map[string]interface{}
+		if data, err = b.decode(ctx, obj); err == nil {
+			return map[string]interface{}{"status": http.StatusOK, "data": data}
+		}
+	}
+
+	respErr := batchError(err)
+	return map[string]interface{}{"status": respErr.StatusCode(), "error": respErr.Error()}
+}
+
+// batchError maps the error of a single key to a short message per status
+// code, so the s3 internals are not exposed to the clients.
+func batchError(err error) client.HTTPResponseError {
+	var respErr client.HTTPResponseError
+	switch {
+	case errors.As(err, &respErr):
+		return respErr
+	case isNotFound(err):
+		return client.HTTPResponseError{Code: http.StatusNotFound, Msg: "aws s3: the object does not exist"}
+	}
+
+	return client.HTTPResponseError{Code: http.StatusBadGateway, Msg: "aws s3: the object could not be fetched"}
+}
+
+// batchKeys returns the distinct keys listed in the "keys" field of the json
+// request body or, when there is no body, in the key query params.
+func batchKeys(request *proxy.Request) ([]string, error) {
+	var keys []string
+	if request.Body != nil {
+		defer request.Body.Close()
+
+		in := struct {
+			Keys []string `json:"keys"`
+		}{}
+		if err := json.NewDecoder(request.Body).Decode(&in); err != nil && err != io.EOF {
+			return nil, errInvalidBatchBody
+		}
+		keys = in.Keys
+	}
+
+	if len(keys) == 0 {
+		keys = request.Query[batchKeyParam]
+	}
+
+	seen := make(map[string]bool, len(keys))
+	distinct := make([]string, 0, len(keys))
+	for _, k := range keys {
+		k = strings.TrimPrefix(k, "/")
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		distinct = append(distinct, k)
+	}
+
+	if len(distinct) == 0 {
+		return nil, errNoBatchKeys
+	}
+
+	return distinct, nil
+}
+
+func getBatchOptions(cfg map[string]interface{}, opts *Options) error {
+	if opts.Operation != OperationBatchGet {
+		return nil
+	}
+
+	opts.MaxBatchSize = defaultMaxBatchSize
+	if _, ok := cfg["max_batch_size"]; ok {
+		size, ok := getInt(cfg, "max_batch_size")
+		if !ok || size < 1 {
+			return errInvalidBatch
+		}
+		opts.MaxBatchSize = size
+	}
+
+	opts.BatchConcurrency = defaultBatchConcurrency
+	if _, ok := cfg["batch_concurrency"]; ok {
+		concurrency, ok := getInt(cfg, "batch_concurrency")
+		if !ok || concurrency < 1 {
+			return errInvalidBatch
+		}
+		opts.BatchConcurrency = concurrency
+	}
+
+	return nil
+}
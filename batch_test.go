@@ -0,0 +1,196 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_batchGet(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *proxy.Request
+		setup    func(client *mocks.MockObjectGetter)
+		wantData map[string]interface{}
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name: "keys in the body, should return the content or error of each key",
+			request: &proxy.Request{
+				Body: io.NopCloser(
+					strings.NewReader(`{"keys": ["i18n/en.json", "/i18n/fr.json", "i18n/en.json", "i18n/de.json", "private/secrets.json"]}`),
+				),
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "i18n/en.json").Times(1).Return(objectOutput(`{"hello": "Hello"}`), nil)
+				expectGetObject(client, "i18n/fr.json").Times(1).Return(objectOutput(`{"hello": "Bonjour"}`), nil)
+				expectGetObject(client, "i18n/de.json").Times(1).Return(nil, &types.NoSuchKey{})
+			},
+			wantData: map[string]interface{}{
+				"i18n/en.json": map[string]interface{}{"status": http.StatusOK, "data": map[string]interface{}{"hello": "Hello"}},
+				"i18n/fr.json": map[string]interface{}{"status": http.StatusOK, "data": map[string]interface{}{"hello": "Bonjour"}},
+				"i18n/de.json": map[string]interface{}{
+					"status": http.StatusNotFound,
+					"error":  "aws s3: the object does not exist",
+				},
+				"private/secrets.json": map[string]interface{}{
+					"status": http.StatusForbidden,
+					"error":  "aws s3: access to the object key is not allowed",
+				},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "keys in the query, should return the content of each key",
+			request: &proxy.Request{
+				Query: url.Values{"key": {"i18n/en.json"}},
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "i18n/en.json").Times(1).Return(objectOutput(`{"hello": "Hello"}`), nil)
+			},
+			wantData: map[string]interface{}{
+				"i18n/en.json": map[string]interface{}{"status": http.StatusOK, "data": map[string]interface{}{"hello": "Hello"}},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "s3 error, should not expose the s3 error message",
+			request: &proxy.Request{
+				Query: url.Values{"key": {"i18n/en.json"}},
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "i18n/en.json").Times(1).Return(nil, errors.New("bucket1: request id 4442587FB7D0A2F9"))
+			},
+			wantData: map[string]interface{}{
+				"i18n/en.json": map[string]interface{}{
+					"status": http.StatusBadGateway,
+					"error":  "aws s3: the object could not be fetched",
+				},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "no keys, should return 400",
+			request: &proxy.Request{Body: io.NopCloser(strings.NewReader(`{"keys": []}`))},
+			setup:   func(client *mocks.MockObjectGetter) {},
+			wantErr: wantStatusCode(http.StatusBadRequest),
+		},
+		{
+			name:    "invalid body, should return 400",
+			request: &proxy.Request{Body: io.NopCloser(strings.NewReader(`["i18n/en.json"]`))},
+			setup:   func(client *mocks.MockObjectGetter) {},
+			wantErr: wantStatusCode(http.StatusBadRequest),
+		},
+		{
+			name: "too many keys, should return 413",
+			request: &proxy.Request{
+				Query: url.Values{"key": {"i18n/1.json", "i18n/2.json", "i18n/3.json", "i18n/4.json", "i18n/5.json"}},
+			},
+			setup:   func(client *mocks.MockObjectGetter) {},
+			wantErr: wantStatusCode(http.StatusRequestEntityTooLarge),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				tt.setup(cl)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":           "bucket1",
+								"operation":        "batch_get",
+								"max_batch_size":   4,
+								"allowed_prefixes": []interface{}{"i18n/"},
+							},
+						},
+					},
+				)
+
+				got, err := p(context.Background(), tt.request)
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				assert.Equal(t, tt.wantData, got.Data)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_batchGetConcurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+
+	var running, maxRunning int32
+	cl.EXPECT().GetObject(gomock.Any(), gomock.Any()).Times(6).DoAndReturn(
+		func(_ context.Context, _ *awsS3.GetObjectInput, _ ...func(*awsS3.Options)) (*awsS3.GetObjectOutput, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			return objectOutput(`{}`), nil
+		},
+	)
+
+	b := s3.BackendFactoryWithClient(
+		logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":            "bucket1",
+					"operation":         "batch_get",
+					"batch_concurrency": 2,
+				},
+			},
+		},
+	)
+
+	got, err := p(
+		context.Background(), &proxy.Request{
+			Query: url.Values{"key": {"1", "2", "3", "4", "5", "6"}},
+		},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, got.Data, 6)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
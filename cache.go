@@ -0,0 +1,114 @@
+package s3
+
+import (
+	"sync"
+	"time"
+)
+
+// objectCache keeps the last good copy of the fetched objects in memory,
+// indexed by bucket and key.
+type objectCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	stale   time.Duration
+	entries map[string]cacheEntry
+	swept   time.Time
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	object    *object
+	fetchedAt time.Time
+}
+
+func newObjectCache(ttl, stale time.Duration) *objectCache {
+	if ttl <= 0 && stale <= 0 {
+		return nil
+	}
+
+	return &objectCache{
+		ttl:     ttl,
+		stale:   stale,
+		entries: map[string]cacheEntry{},
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached object if it is still fresh.
+func (c *objectCache) Get(bucket, key string) (*object, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	return c.lookup(bucket, key, c.ttl)
+}
+
+// GetStale returns the cached object if it is within the configured staleness
+// window, which starts once the object is no longer fresh.
+func (c *objectCache) GetStale(bucket, key string) (*object, bool) {
+	if c == nil || c.stale <= 0 {
+		return nil, false
+	}
+
+	return c.lookup(bucket, key, c.ttl+c.stale)
+}
+
+// Set stores the object as the last good copy for the given bucket and key.
+func (c *objectCache) Set(bucket, key string, obj *object) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.entries[cacheKey(bucket, key)] = cacheEntry{object: obj, fetchedAt: now}
+
+	maxAge := c.maxAge()
+	if now.Sub(c.swept) < maxAge {
+		return
+	}
+
+	for k, e := range c.entries {
+		if now.Sub(e.fetchedAt) > maxAge {
+			delete(c.entries, k)
+		}
+	}
+	c.swept = now
+}
+
+// Delete removes the cached copy of the object, after it has been modified.
+func (c *objectCache) Delete(bucket, key string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.entries, cacheKey(bucket, key))
+	c.mu.Unlock()
+}
+
+func (c *objectCache) lookup(bucket, key string, maxAge time.Duration) (*object, bool) {
+	if maxAge <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	e, ok := c.entries[cacheKey(bucket, key)]
+	c.mu.RUnlock()
+
+	if !ok || c.now().Sub(e.fetchedAt) > maxAge {
+		return nil, false
+	}
+
+	return e.object, true
+}
+
+func (c *objectCache) maxAge() time.Duration {
+	return c.ttl + c.stale
+}
+
+func cacheKey(bucket, key string) string {
+	return bucket + "/" + key
+}
@@ -0,0 +1,78 @@
+package s3
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectCache_GetStale(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		stale     time.Duration
+		age       time.Duration
+		wantFresh bool
+		wantStale bool
+	}{
+		{
+			name:      "fresh copy, should be served as fresh and stale",
+			ttl:       time.Minute,
+			stale:     time.Minute,
+			age:       30 * time.Second,
+			wantFresh: true,
+			wantStale: true,
+		},
+		{
+			name:      "copy expired within the window, should only be served as stale",
+			ttl:       time.Minute,
+			stale:     time.Minute,
+			age:       90 * time.Second,
+			wantStale: true,
+		},
+		{
+			name:  "copy older than the window, should not be served",
+			ttl:   time.Minute,
+			stale: time.Minute,
+			age:   150 * time.Second,
+		},
+		{
+			name:      "no stale window, should not be served as stale",
+			ttl:       time.Minute,
+			age:       30 * time.Second,
+			wantFresh: true,
+		},
+		{
+			name:      "no ttl, should be served as stale within the window",
+			stale:     time.Minute,
+			age:       30 * time.Second,
+			wantStale: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				now := time.Now()
+				c := newObjectCache(tt.ttl, tt.stale)
+				c.now = func() time.Time { return now }
+
+				obj := &object{}
+				c.Set("bucket1", "sample", obj)
+				now = now.Add(tt.age)
+
+				got, ok := c.Get("bucket1", "sample")
+				assert.Equal(t, tt.wantFresh, ok)
+				if ok {
+					assert.Same(t, obj, got)
+				}
+
+				got, ok = c.GetStale("bucket1", "sample")
+				assert.Equal(t, tt.wantStale, ok)
+				if ok {
+					assert.Same(t, obj, got)
+				}
+			},
+		)
+	}
+}
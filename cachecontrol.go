@@ -0,0 +1,135 @@
+package s3
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/luraproject/lura/v2/proxy"
+)
+
+var errInvalidCacheHeaders = errors.New(`aws s3: invalid "cache_headers" or "prefer_object_cache_control" defined`)
+
+// CacheHeaderRule sets the caching headers of the responses of the objects
+// whose key matches Pattern and whose content type matches ContentType, any
+// of them matching everything when not defined.
+type CacheHeaderRule struct {
+	Pattern      *keyPattern
+	ContentType  string
+	CacheControl string
+	Expires      time.Duration
+	Vary         []string
+}
+
+func (r CacheHeaderRule) matches(obj *object) bool {
+	if r.Pattern != nil && !r.Pattern.match(obj.key) {
+		return false
+	}
+
+	return r.ContentType == "" || matchesMediaRange(r.ContentType, obj.contentType)
+}
+
+// setCacheHeaders adds the Cache-Control, Expires and Vary headers of the
+// first rule matching the object, using the Cache-Control stored along with
+// the object instead when PreferObjectCacheControl is enabled.
+func (b *backend) setCacheHeaders(response *proxy.Response, obj *object) {
+	headers := response.Metadata.Headers
+
+	for _, rule := range b.opts.CacheHeaders {
+		if !rule.matches(obj) {
+			continue
+		}
+
+		if rule.CacheControl != "" {
+			headers["Cache-Control"] = []string{rule.CacheControl}
+		}
+
+		if rule.Expires > 0 {
+			headers["Expires"] = []string{time.Now().Add(rule.Expires).UTC().Format(http.TimeFormat)}
+		}
+
+		for _, v := range rule.Vary {
+			if !contains(headers["Vary"], v) {
+				headers["Vary"] = append(headers["Vary"], v)
+			}
+		}
+
+		break
+	}
+
+	if b.opts.PreferObjectCacheControl && obj.cacheControl != "" {
+		headers["Cache-Control"] = []string{obj.cacheControl}
+	}
+}
+
+func getCacheHeaders(cfg map[string]interface{}, opts *Options) error {
+	if v, ok := cfg["prefer_object_cache_control"]; ok {
+		if opts.PreferObjectCacheControl, ok = v.(bool); !ok {
+			return errInvalidCacheHeaders
+		}
+	}
+
+	v, ok := cfg["cache_headers"]
+	if !ok {
+		return nil
+	}
+
+	rules, ok := v.([]interface{})
+	if !ok {
+		return errInvalidCacheHeaders
+	}
+
+	for _, r := range rules {
+		rc, ok := r.(map[string]interface{})
+		if !ok {
+			return errInvalidCacheHeaders
+		}
+
+		rule := CacheHeaderRule{}
+		if p, ok := rc["pattern"]; ok {
+			s, ok := p.(string)
+			if !ok {
+				return errInvalidCacheHeaders
+			}
+
+			var err error
+			if rule.Pattern, err = newKeyPattern(s); err != nil {
+				return errInvalidCacheHeaders
+			}
+		}
+
+		if ct, ok := rc["content_type"]; ok {
+			if rule.ContentType, ok = ct.(string); !ok || rule.ContentType == "" {
+				return errInvalidCacheHeaders
+			}
+		}
+
+		if cc, ok := rc["cache_control"]; ok {
+			if rule.CacheControl, ok = cc.(string); !ok {
+				return errInvalidCacheHeaders
+			}
+		}
+
+		var err error
+		if rule.Expires, err = getDuration(rc, "expires"); err != nil {
+			return errInvalidCacheHeaders
+		}
+
+		if vary, ok := rc["vary"]; ok {
+			if rule.Vary, err = getStrings(vary); err != nil {
+				return errInvalidCacheHeaders
+			}
+			for i, h := range rule.Vary {
+				rule.Vary[i] = http.CanonicalHeaderKey(h)
+			}
+		}
+
+		if rule.CacheControl == "" && rule.Expires == 0 && len(rule.Vary) == 0 {
+			return errInvalidCacheHeaders
+		}
+
+		opts.CacheHeaders = append(opts.CacheHeaders, rule)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package s3_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_cacheHeaders(t *testing.T) {
+	rules := []interface{}{
+		map[string]interface{}{
+			"pattern":       "i18n/*.json",
+			"cache_control": "public, max-age=300",
+			"expires":       "5m",
+			"vary":          []interface{}{"accept-language"},
+		},
+		map[string]interface{}{
+			"content_type":  "text/*",
+			"cache_control": "no-cache",
+		},
+	}
+
+	tests := []struct {
+		name             string
+		path             string
+		cacheControl     string
+		contentType      string
+		preferObject     bool
+		wantCacheControl []string
+		wantVary         []string
+		wantExpires      bool
+	}{
+		{
+			name:             "key matching a pattern, should set the headers of the rule",
+			path:             "/i18n/en.json",
+			wantCacheControl: []string{"public, max-age=300"},
+			wantVary:         []string{"Accept-Language"},
+			wantExpires:      true,
+		},
+		{
+			name:             "content type matching a rule, should set the headers of the rule",
+			path:             "/readme",
+			contentType:      "text/plain; charset=utf-8",
+			wantCacheControl: []string{"no-cache"},
+		},
+		{
+			name:             "first matching rule, should win",
+			path:             "/i18n/en.json",
+			contentType:      "text/plain",
+			wantCacheControl: []string{"public, max-age=300"},
+			wantVary:         []string{"Accept-Language"},
+			wantExpires:      true,
+		},
+		{
+			name: "no matching rule, should not set any header",
+			path: "/data.json",
+		},
+		{
+			name:             "object cache control not preferred, should be ignored",
+			path:             "/readme",
+			contentType:      "text/plain",
+			cacheControl:     "max-age=60",
+			wantCacheControl: []string{"no-cache"},
+		},
+		{
+			name:             "object cache control preferred, should override the rule",
+			path:             "/i18n/en.json",
+			cacheControl:     "max-age=60",
+			preferObject:     true,
+			wantCacheControl: []string{"max-age=60"},
+			wantVary:         []string{"Accept-Language"},
+			wantExpires:      true,
+		},
+		{
+			name:             "object cache control preferred without matching rule, should be used",
+			path:             "/data.json",
+			cacheControl:     "max-age=60",
+			preferObject:     true,
+			wantCacheControl: []string{"max-age=60"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				out := objectOutput(`{"hello": "world"}`)
+				if tt.contentType != "" {
+					out.ContentType = aws.String(tt.contentType)
+				}
+				if tt.cacheControl != "" {
+					out.CacheControl = aws.String(tt.cacheControl)
+				}
+				expectGetObject(cl, tt.path[1:]).Return(out, nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":                      "bucket1",
+								"cache_headers":               rules,
+								"prefer_object_cache_control": tt.preferObject,
+							},
+						},
+					},
+				)
+
+				got, err := p(context.Background(), &proxy.Request{Path: tt.path})
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.wantCacheControl, got.Metadata.Headers["Cache-Control"])
+				assert.Equal(t, tt.wantVary, got.Metadata.Headers["Vary"])
+
+				if !tt.wantExpires {
+					assert.NotContains(t, got.Metadata.Headers, "Expires")
+					return
+				}
+
+				expires, err := http.ParseTime(got.Metadata.Headers["Expires"][0])
+				assert.NoError(t, err)
+				assert.WithinDuration(t, time.Now().Add(5*time.Minute), expires, 2*time.Second)
+			},
+		)
+	}
+}
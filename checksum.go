@@ -0,0 +1,74 @@
+package s3
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+var errChecksumMismatch = client.HTTPResponseError{Code: http.StatusBadGateway, Msg: "aws s3: object checksum mismatch"}
+
+// verifyChecksum compares the object content with the checksums returned by
+// s3: the additional checksum the object was uploaded with, if any, or the
+// ETag, which is the MD5 of the content for objects uploaded in a single part
+// and not encrypted with kms or customer keys. Checksums of multipart objects, suffixed with
+// the number of parts, can not be computed from the content and are skipped.
+func verifyChecksum(out *s3.GetObjectOutput, body []byte) error {
+	checksums := []struct {
+		value string
+		hash  func() hash.Hash
+	}{
+		{aws.ToString(out.ChecksumSHA256), sha256.New},
+		{aws.ToString(out.ChecksumSHA1), sha1.New},
+		{aws.ToString(out.ChecksumCRC32C), func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) }},
+		{aws.ToString(out.ChecksumCRC32), func() hash.Hash { return crc32.NewIEEE() }},
+	}
+
+	for _, c := range checksums {
+		if c.value == "" || strings.Contains(c.value, "-") {
+			continue
+		}
+
+		h := c.hash()
+		h.Write(body)
+		if base64.StdEncoding.EncodeToString(h.Sum(nil)) != c.value {
+			return errChecksumMismatch
+		}
+
+		return nil
+	}
+
+	etag := strings.Trim(aws.ToString(out.ETag), `"`)
+	if etag == "" || strings.Contains(etag, "-") ||
+		out.ServerSideEncryption == types.ServerSideEncryptionAwsKms || out.SSECustomerAlgorithm != nil {
+		return nil
+	}
+
+	sum := md5.Sum(body)
+	if hex.EncodeToString(sum[:]) != etag {
+		return errChecksumMismatch
+	}
+
+	return nil
+}
+
+// readError replaces the error returned by the s3 client when the checksum it
+// validates while reading the object does not match with errChecksumMismatch.
+func readError(err error) error {
+	if strings.Contains(err.Error(), "checksum did not match") {
+		return errChecksumMismatch
+	}
+
+	return err
+}
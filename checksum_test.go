@@ -0,0 +1,153 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_validateChecksum(t *testing.T) {
+	const body = `{"property1": "value1"}`
+
+	output := func(fn func(out *awsS3.GetObjectOutput)) *awsS3.GetObjectOutput {
+		out := objectOutput(body)
+		fn(out)
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		validate bool
+		out      *awsS3.GetObjectOutput
+		wantErr  assert.ErrorAssertionFunc
+	}{
+		{
+			name:     "sha256 matching, should return the object",
+			validate: true,
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ChecksumSHA256 = aws.String("E53lFKA0eFC42IzFij51Ny9WWHZu1cBOxQr9QhmCXgg=")
+			}),
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "crc32c matching, should return the object",
+			validate: true,
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ChecksumCRC32C = aws.String("da3WpQ==")
+			}),
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "crc32 not matching, should return 502",
+			validate: true,
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ChecksumCRC32 = aws.String("da3WpQ==")
+			}),
+			wantErr: wantStatusCode(http.StatusBadGateway),
+		},
+		{
+			name:     "etag matching, should return the object",
+			validate: true,
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ETag = aws.String(`"c0f84c51e53a7c60514d20e80e6ece01"`)
+			}),
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "etag not matching, should return 502",
+			validate: true,
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ETag = aws.String(`"d41d8cd98f00b204e9800998ecf8427e"`)
+			}),
+			wantErr: wantStatusCode(http.StatusBadGateway),
+		},
+		{
+			name:     "etag of a multipart object, should skip the validation",
+			validate: true,
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ETag = aws.String(`"d41d8cd98f00b204e9800998ecf8427e-2"`)
+			}),
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "etag of a kms encrypted object, should skip the validation",
+			validate: true,
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ETag = aws.String(`"d41d8cd98f00b204e9800998ecf8427e"`)
+				out.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+			}),
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "checksum validated by the client not matching, should return 502",
+			validate: true,
+			out: &awsS3.GetObjectOutput{
+				Body: io.NopCloser(
+					io.MultiReader(
+						strings.NewReader(body),
+						faultyReader{errors.New("checksum did not match: algorithm CRC32, expect da3WpQ==, actual AAAAAA==")},
+					),
+				),
+			},
+			wantErr: wantStatusCode(http.StatusBadGateway),
+		},
+		{
+			name: "validation disabled, should return the object",
+			out: output(func(out *awsS3.GetObjectOutput) {
+				out.ETag = aws.String(`"d41d8cd98f00b204e9800998ecf8427e"`)
+			}),
+			wantErr: assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+
+				in := &awsS3.GetObjectInput{
+					Bucket: aws.String("bucket1"),
+					Key:    aws.String("sample"),
+				}
+				if tt.validate {
+					in.ChecksumMode = types.ChecksumModeEnabled
+				}
+				cl.EXPECT().GetObject(gomock.Any(), gomock.Eq(in)).Times(1).Return(tt.out, nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":            "bucket1",
+								"validate_checksum": tt.validate,
+							},
+						},
+					},
+				)
+
+				_, err := p(context.Background(), &proxy.Request{Path: "/sample"})
+				tt.wantErr(t, err)
+			},
+		)
+	}
+}
@@ -0,0 +1,153 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+const (
+	encodingGzip   = "gzip"
+	encodingZstd   = "zstd"
+	encodingBrotli = "br"
+
+	// defaultMaxDecompressedSize bounds the memory used to decompress an
+	// object, so a small compressed object can't exhaust it.
+	defaultMaxDecompressedSize = 64 << 20
+)
+
+var (
+	errInvalidMaxDecompressedSize = errors.New(`aws s3: invalid "max_decompressed_size" defined`)
+	errDecompressedTooLarge       = client.HTTPResponseError{
+		Code: http.StatusBadGateway,
+		Msg:  "aws s3: the decompressed object exceeds the maximum size",
+	}
+)
+
+// keySuffixEncodings maps the key suffixes of compressed objects to their encoding.
+var keySuffixEncodings = map[string]string{
+	".gz":   encodingGzip,
+	".gzip": encodingGzip,
+	".zst":  encodingZstd,
+	".br":   encodingBrotli,
+}
+
+// encoding returns the compression of the object, taken from its
+// ContentEncoding or, when it has no known compression, from the suffix of
+// its key. Values that are not compressions, like utf-8 or aws-chunked, are
+// often stored as the ContentEncoding and so are ignored.
+func (o *object) encoding() string {
+	encodings := strings.Split(o.contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		switch e := strings.ToLower(strings.TrimSpace(encodings[i])); e {
+		case encodingGzip, "x-gzip":
+			return encodingGzip
+		case encodingZstd, encodingBrotli:
+			return e
+		case "identity":
+			return ""
+		}
+	}
+
+	return keySuffixEncodings[strings.ToLower(path.Ext(o.key))]
+}
+
+// content returns the object body, decompressed if needed, failing when the
+// decompressed content is larger than maxSize bytes, or than
+// defaultMaxDecompressedSize when not set.
+func (o *object) content(maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxDecompressedSize
+	}
+
+	var r io.Reader
+	switch o.encoding() {
+	case "":
+		return o.body, nil
+	case encodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(o.body))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	case encodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(o.body))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case encodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(o.body))
+	}
+
+	cont, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(cont)) > maxSize {
+		return nil, errDecompressedTooLarge
+	}
+
+	return cont, nil
+}
+
+// acceptsEncoding reports whether the Accept-Encoding request header allows
+// the given content encoding.
+func acceptsEncoding(headers map[string][]string, encoding string) bool {
+	for _, h := range headers["Accept-Encoding"] {
+		for _, v := range strings.Split(h, ",") {
+			parts := strings.Split(v, ";")
+			name := strings.ToLower(strings.TrimSpace(parts[0]))
+			if name != encoding && name != "*" {
+				continue
+			}
+
+			if !zeroQuality(parts[1:]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// zeroQuality reports whether the params of an Accept-Encoding value set its
+// quality to zero, meaning the encoding is not acceptable.
+func zeroQuality(params []string) bool {
+	for _, p := range params {
+		p = strings.TrimSpace(p)
+		if !strings.HasPrefix(p, "q=") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(p[2:], 64)
+		return err == nil && q == 0
+	}
+
+	return false
+}
+
+func getMaxDecompressedSize(cfg map[string]interface{}) (int64, error) {
+	if _, ok := cfg["max_decompressed_size"]; !ok {
+		return 0, nil
+	}
+
+	n, ok := getInt(cfg, "max_decompressed_size")
+	if !ok || n < 1 {
+		return 0, errInvalidMaxDecompressedSize
+	}
+
+	return int64(n), nil
+}
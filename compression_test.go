@@ -0,0 +1,244 @@
+package s3_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/klauspost/compress/zstd"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/encoding"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+const compressedContent = `{"property1": "value1"}`
+
+func TestBackendFactoryWithClient_decompress(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		output *awsS3.GetObjectOutput
+	}{
+		{
+			name:   "gzip content encoding, should decompress the object",
+			key:    "sample",
+			output: compressedOutput(gzipped(t, compressedContent), "gzip"),
+		},
+		{
+			name:   "zstd content encoding, should decompress the object",
+			key:    "sample",
+			output: compressedOutput(zstdCompressed(t, compressedContent), "zstd"),
+		},
+		{
+			name:   "brotli content encoding, should decompress the object",
+			key:    "sample",
+			output: compressedOutput(brotliCompressed(t, compressedContent), "br"),
+		},
+		{
+			name:   "gz key suffix, should decompress the object",
+			key:    "sample.json.gz",
+			output: compressedOutput(gzipped(t, compressedContent), ""),
+		},
+		{
+			name:   "zst key suffix, should decompress the object",
+			key:    "sample.json.zst",
+			output: compressedOutput(zstdCompressed(t, compressedContent), ""),
+		},
+		{
+			name:   "identity content encoding, should not decompress the object",
+			key:    "sample.json.gz",
+			output: compressedOutput([]byte(compressedContent), "identity"),
+		},
+		{
+			name:   "unknown content encoding, should not decompress the object",
+			key:    "sample.json",
+			output: compressedOutput([]byte(compressedContent), "utf-8"),
+		},
+		{
+			name:   "unknown content encoding and gz key suffix, should decompress the object",
+			key:    "sample.json.gz",
+			output: compressedOutput(gzipped(t, compressedContent), "aws-chunked"),
+		},
+		{
+			name:   "compression before an unknown content encoding, should decompress the object",
+			key:    "sample",
+			output: compressedOutput(gzipped(t, compressedContent), "gzip, aws-chunked"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				expectGetObject(cl, tt.key).Return(tt.output, nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: map[string]interface{}{"bucket": "bucket1"}}})
+
+				got, err := p(context.Background(), &proxy.Request{Path: "/" + tt.key})
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, map[string]interface{}{"property1": "value1"}, got.Data)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_passthroughCompressed(t *testing.T) {
+	gz := gzipped(t, compressedContent)
+
+	tests := []struct {
+		name         string
+		config       map[string]interface{}
+		headers      map[string][]string
+		wantBody     []byte
+		wantEncoding []string
+		wantVary     []string
+	}{
+		{
+			name:         "client accepts the encoding, should forward the compressed object",
+			config:       map[string]interface{}{"bucket": "bucket1", "forward_compressed": true},
+			headers:      map[string][]string{"Accept-Encoding": {"br, gzip;q=0.8"}},
+			wantBody:     gz,
+			wantEncoding: []string{"gzip"},
+			wantVary:     []string{"Accept-Encoding"},
+		},
+		{
+			name:     "client rejects the encoding, should decompress the object",
+			config:   map[string]interface{}{"bucket": "bucket1", "forward_compressed": true},
+			headers:  map[string][]string{"Accept-Encoding": {"br, gzip;q=0"}},
+			wantBody: []byte(compressedContent),
+			wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name:     "client without Accept-Encoding, should decompress the object",
+			config:   map[string]interface{}{"bucket": "bucket1", "forward_compressed": true},
+			wantBody: []byte(compressedContent),
+			wantVary: []string{"Accept-Encoding"},
+		},
+		{
+			name:     "forward_compressed disabled, should decompress the object",
+			config:   map[string]interface{}{"bucket": "bucket1"},
+			headers:  map[string][]string{"Accept-Encoding": {"gzip"}},
+			wantBody: []byte(compressedContent),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				expectGetObject(cl, "sample").Return(compressedOutput(gz, "gzip"), nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{Encoding: encoding.NOOP, ExtraConfig: map[string]interface{}{s3.Namespace: tt.config}})
+
+				got, err := p(context.Background(), &proxy.Request{Path: "/sample", Headers: tt.headers})
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				body, err := io.ReadAll(got.Io)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBody, body)
+				assert.Equal(t, tt.wantEncoding, got.Metadata.Headers["Content-Encoding"])
+				assert.Equal(t, tt.wantVary, got.Metadata.Headers["Vary"])
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_decompressTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+	expectGetObject(cl, "sample").Return(compressedOutput(gzipped(t, strings.Repeat(" ", 1<<20)), "gzip"), nil)
+
+	b := s3.BackendFactoryWithClient(
+		logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{"bucket": "bucket1", "max_decompressed_size": 1024},
+			},
+		},
+	)
+
+	_, err := p(context.Background(), &proxy.Request{Path: "/sample"})
+	wantStatusCode(http.StatusBadGateway)(t, err)
+}
+
+func compressedOutput(body []byte, contentEncoding string) *awsS3.GetObjectOutput {
+	out := &awsS3.GetObjectOutput{
+		Body:        io.NopCloser(bytes.NewReader(body)),
+		ContentType: aws.String("application/json"),
+	}
+	if contentEncoding != "" {
+		out.ContentEncoding = aws.String(contentEncoding)
+	}
+
+	return out
+}
+
+func gzipped(t *testing.T, s string) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func zstdCompressed(t *testing.T, s string) []byte {
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	return w.EncodeAll([]byte(s), nil)
+}
+
+func brotliCompressed(t *testing.T, s string) []byte {
+	buf := &bytes.Buffer{}
+	w := brotli.NewWriter(buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,270 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+var (
+	errInvalidCopy     = errors.New(`aws s3: invalid "copy" defined`)
+	errCopyUnsupported = errors.New("aws s3: the s3 client does not support copies")
+	errSameKey         = client.HTTPResponseError{Code: http.StatusBadRequest, Msg: "aws s3: the source and destination keys are the same"}
+)
+
+// CopyOptions define the source and destination of the copy and move
+// operations. The keys and IfMatch may contain placeholders resolved from the
+// request, like the merged keys.
+type CopyOptions struct {
+	SourceKey         string
+	DestinationKey    string
+	MetadataDirective types.MetadataDirective
+	Metadata          map[string]string
+	TaggingDirective  types.TaggingDirective
+	Tags              map[string]string
+	// IfMatch is the ETag the source object must have for the copy to
+	// happen, i.e. {header.If-Match}.
+	IfMatch string
+}
+
+// copyObject copies the source object into the destination key and, for the
+// move operation, deletes the source once copied.
+func (b *backend) copyObject(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	t := b.targets[0]
+	copier, ok := t.client.(ObjectCopier)
+	if !ok {
+		return nil, errCopyUnsupported
+	}
+
+	opts := b.opts.Copy
+	src, dst := expandKey(opts.SourceKey, request), expandKey(opts.DestinationKey, request)
+	// different templates can still resolve to the same key, which a move
+	// would copy onto itself and then delete.
+	if src == dst {
+		return nil, errSameKey
+	}
+
+	for _, k := range []string{src, dst} {
+		if err := b.checkAccess(k); err != nil {
+			return nil, err
+		}
+	}
+
+	in := &s3.CopyObjectInput{
+		Bucket:            &t.bucket,
+		Key:               &dst,
+		CopySource:        aws.String(copySource(t.bucket, src)),
+		MetadataDirective: opts.MetadataDirective,
+		Metadata:          opts.Metadata,
+		TaggingDirective:  opts.TaggingDirective,
+	}
+	if len(opts.Tags) > 0 {
+		in.Tagging = aws.String(encodeTags(opts.Tags))
+	}
+	if ifMatch := expandKey(opts.IfMatch, request); ifMatch != "" {
+		in.CopySourceIfMatch = aws.String(ifMatch)
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+		in.ExpectedSourceBucketOwner = aws.String(t.owner)
+	}
+	if b.opts.RequestPayer {
+		in.RequestPayer = types.RequestPayerRequester
+	}
+
+	start := time.Now()
+	out, err := copier.CopyObject(ctx, in)
+	metadata := middleware.Metadata{}
+	if out != nil {
+		metadata = out.ResultMetadata
+	}
+	b.metrics.observe(ctx, "CopyObject", t.bucket, start, 0, err)
+	b.logOperation("CopyObject", t.bucket, dst, start, metadata, err)
+	if err != nil {
+		return nil, copyError(err)
+	}
+
+	if b.opts.Operation == OperationMove {
+		if err := b.deleteObject(ctx, copier, t, src); err != nil {
+			return nil, err
+		}
+	}
+
+	etag := ""
+	if out.CopyObjectResult != nil {
+		etag = aws.ToString(out.CopyObjectResult.ETag)
+	}
+
+	return b.format(
+		ctx, proxy.Response{
+			Data: map[string]interface{}{
+				"source_key": src,
+				"key":        dst,
+				"etag":       etag,
+				"version_id": aws.ToString(out.VersionId),
+			},
+			IsComplete: true,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: http.StatusOK,
+			},
+		},
+	), nil
+}
+
+func (b *backend) deleteObject(ctx context.Context, copier ObjectCopier, t target, k string) error {
+	in := &s3.DeleteObjectInput{
+		Bucket: &t.bucket,
+		Key:    &k,
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+	}
+	if b.opts.RequestPayer {
+		in.RequestPayer = types.RequestPayerRequester
+	}
+
+	start := time.Now()
+	out, err := copier.DeleteObject(ctx, in)
+	metadata := middleware.Metadata{}
+	if out != nil {
+		metadata = out.ResultMetadata
+	}
+	b.metrics.observe(ctx, "DeleteObject", t.bucket, start, 0, err)
+	b.logOperation("DeleteObject", t.bucket, k, start, metadata, err)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// copySource returns the url encoded bucket and key of the source object.
+func copySource(bucket, k string) string {
+	segments := strings.Split(k, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	return bucket + "/" + strings.Join(segments, "/")
+}
+
+// encodeTags returns the tags in the query string format expected by s3.
+func encodeTags(tags map[string]string) string {
+	vs := url.Values{}
+	for k, v := range tags {
+		vs.Set(k, v)
+	}
+
+	return vs.Encode()
+}
+
+// copyError turns the errors of a copy caused by the request, a missing
+// source or a failed If-Match condition, into 404 and 412 errors.
+func copyError(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	switch apiErr.ErrorCode() {
+	case "NoSuchKey":
+		return client.HTTPResponseError{Code: http.StatusNotFound, Msg: "aws s3: the source object does not exist"}
+	case "PreconditionFailed":
+		return client.HTTPResponseError{Code: http.StatusPreconditionFailed, Msg: "aws s3: the source object does not match the If-Match condition"}
+	}
+
+	return err
+}
+
+func getCopyOptions(cfg map[string]interface{}, operation string) (*CopyOptions, error) {
+	if operation != OperationCopy && operation != OperationMove {
+		return nil, nil
+	}
+
+	cc, ok := cfg["copy"].(map[string]interface{})
+	if !ok {
+		return nil, errInvalidCopy
+	}
+
+	opts := &CopyOptions{}
+	opts.SourceKey, _ = cc["source_key"].(string)
+	opts.DestinationKey, _ = cc["destination_key"].(string)
+	if opts.SourceKey == "" || opts.DestinationKey == "" || opts.SourceKey == opts.DestinationKey {
+		return nil, errInvalidCopy
+	}
+
+	if v, ok := cc["metadata_directive"]; ok {
+		directive, ok := v.(string)
+		opts.MetadataDirective = types.MetadataDirective(strings.ToUpper(directive))
+		if !ok || (opts.MetadataDirective != types.MetadataDirectiveCopy && opts.MetadataDirective != types.MetadataDirectiveReplace) {
+			return nil, errInvalidCopy
+		}
+	}
+
+	var err error
+	if opts.Metadata, err = getStringMap(cc, "metadata"); err != nil {
+		return nil, errInvalidCopy
+	}
+
+	if v, ok := cc["tagging_directive"]; ok {
+		directive, ok := v.(string)
+		opts.TaggingDirective = types.TaggingDirective(strings.ToUpper(directive))
+		if !ok || (opts.TaggingDirective != types.TaggingDirectiveCopy && opts.TaggingDirective != types.TaggingDirectiveReplace) {
+			return nil, errInvalidCopy
+		}
+	}
+
+	if opts.Tags, err = getStringMap(cc, "tags"); err != nil {
+		return nil, errInvalidCopy
+	}
+
+	if len(opts.Metadata) > 0 && opts.MetadataDirective != types.MetadataDirectiveReplace {
+		return nil, errInvalidCopy
+	}
+
+	if len(opts.Tags) > 0 && opts.TaggingDirective != types.TaggingDirectiveReplace {
+		return nil, errInvalidCopy
+	}
+
+	if ifMatch, ok := cc["if_match"].(string); ok {
+		opts.IfMatch = ifMatch
+	}
+
+	return opts, nil
+}
+
+// getStringMap converts an object of string values from the config.
+func getStringMap(cfg map[string]interface{}, name string) (map[string]string, error) {
+	v, ok := cfg[name]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	vs, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errInvalidConfig
+	}
+
+	res := make(map[string]string, len(vs))
+	for k, v := range vs {
+		s, ok := v.(string)
+		if !ok || k == "" {
+			return nil, errInvalidConfig
+		}
+		res[k] = s
+	}
+
+	return res, nil
+}
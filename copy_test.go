@@ -0,0 +1,267 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+// copierClient is an s3 client supporting copies.
+type copierClient struct {
+	*mocks.MockObjectGetter
+	*mocks.MockObjectCopier
+}
+
+func TestBackendFactoryWithClient_copy(t *testing.T) {
+	copyCfg := map[string]interface{}{
+		"source_key":         "drafts/{path}",
+		"destination_key":    "published/{path}",
+		"metadata_directive": "replace",
+		"metadata":           map[string]interface{}{"stage": "published"},
+		"tagging_directive":  "REPLACE",
+		"tags":               map[string]interface{}{"classification": "public"},
+		"if_match":           "{header.If-Match}",
+	}
+	copyInput := func(ifMatch *string) *awsS3.CopyObjectInput {
+		return &awsS3.CopyObjectInput{
+			Bucket:            aws.String("bucket1"),
+			Key:               aws.String("published/posts/hello world.json"),
+			CopySource:        aws.String("bucket1/drafts/posts/hello%20world.json"),
+			MetadataDirective: types.MetadataDirectiveReplace,
+			Metadata:          map[string]string{"stage": "published"},
+			TaggingDirective:  types.TaggingDirectiveReplace,
+			Tagging:           aws.String("classification=public"),
+			CopySourceIfMatch: ifMatch,
+		}
+	}
+	copyOutput := &awsS3.CopyObjectOutput{
+		CopyObjectResult: &types.CopyObjectResult{ETag: aws.String(`"etag"`)},
+		VersionId:        aws.String("v2"),
+	}
+	wantResponse := &proxy.Response{
+		Data: map[string]interface{}{
+			"source_key": "drafts/posts/hello world.json",
+			"key":        "published/posts/hello world.json",
+			"etag":       `"etag"`,
+			"version_id": "v2",
+		},
+		IsComplete: true,
+		Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusOK},
+	}
+
+	tests := []struct {
+		name      string
+		operation string
+		headers   map[string][]string
+		setup     func(client *mocks.MockObjectCopier)
+		want      *proxy.Response
+		wantErr   assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "copy, should copy the object",
+			operation: "copy",
+			setup: func(client *mocks.MockObjectCopier) {
+				client.EXPECT().CopyObject(gomock.Any(), gomock.Eq(copyInput(nil))).Times(1).Return(copyOutput, nil)
+			},
+			want:    wantResponse,
+			wantErr: assert.NoError,
+		},
+		{
+			name:      "move, should copy and delete the object",
+			operation: "move",
+			headers:   map[string][]string{"If-Match": {`"etag"`}},
+			setup: func(client *mocks.MockObjectCopier) {
+				gomock.InOrder(
+					client.EXPECT().CopyObject(gomock.Any(), gomock.Eq(copyInput(aws.String(`"etag"`)))).
+						Times(1).Return(copyOutput, nil),
+					client.EXPECT().DeleteObject(
+						gomock.Any(), gomock.Eq(
+							&awsS3.DeleteObjectInput{
+								Bucket: aws.String("bucket1"),
+								Key:    aws.String("drafts/posts/hello world.json"),
+							},
+						),
+					).Times(1).Return(&awsS3.DeleteObjectOutput{}, nil),
+				)
+			},
+			want:    wantResponse,
+			wantErr: assert.NoError,
+		},
+		{
+			name:      "move with If-Match not matching, should return 412 and keep the source",
+			operation: "move",
+			headers:   map[string][]string{"If-Match": {`"other"`}},
+			setup: func(client *mocks.MockObjectCopier) {
+				client.EXPECT().CopyObject(gomock.Any(), gomock.Any()).Times(1).
+					Return(nil, &smithy.GenericAPIError{Code: "PreconditionFailed"})
+			},
+			wantErr: wantStatusCode(http.StatusPreconditionFailed),
+		},
+		{
+			name:      "source missing, should return 404",
+			operation: "copy",
+			setup: func(client *mocks.MockObjectCopier) {
+				client.EXPECT().CopyObject(gomock.Any(), gomock.Any()).Times(1).
+					Return(nil, &smithy.GenericAPIError{Code: "NoSuchKey"})
+			},
+			wantErr: wantStatusCode(http.StatusNotFound),
+		},
+		{
+			name:      "delete failed, should return the error",
+			operation: "move",
+			setup: func(client *mocks.MockObjectCopier) {
+				client.EXPECT().CopyObject(gomock.Any(), gomock.Any()).Times(1).Return(copyOutput, nil)
+				client.EXPECT().DeleteObject(gomock.Any(), gomock.Any()).Times(1).Return(nil, errors.New("connection reset"))
+			},
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := copierClient{mocks.NewMockObjectGetter(ctrl), mocks.NewMockObjectCopier(ctrl)}
+				tt.setup(cl.MockObjectCopier)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":    "bucket1",
+								"operation": tt.operation,
+								"copy":      copyCfg,
+							},
+						},
+					},
+				)
+
+				got, err := p(
+					context.Background(), &proxy.Request{
+						Path:    "/posts/hello world.json",
+						Headers: tt.headers,
+					},
+				)
+				if !tt.wantErr(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_moveSameKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cl := copierClient{mocks.NewMockObjectGetter(ctrl), mocks.NewMockObjectCopier(ctrl)}
+	cl.MockObjectCopier.EXPECT().CopyObject(gomock.Any(), gomock.Any()).Times(0)
+	cl.MockObjectCopier.EXPECT().DeleteObject(gomock.Any(), gomock.Any()).Times(0)
+
+	b := s3.BackendFactoryWithClient(
+		logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":    "bucket1",
+					"operation": "move",
+					"copy": map[string]interface{}{
+						"source_key":      "{from}",
+						"destination_key": "{to}",
+					},
+				},
+			},
+		},
+	)
+
+	_, err := p(
+		context.Background(), &proxy.Request{
+			Params: map[string]string{"From": "posts/hello.json", "To": "posts/hello.json"},
+		},
+	)
+	wantStatusCode(http.StatusBadRequest)(t, err)
+}
+
+func TestBackendFactory_invalidCopy(t *testing.T) {
+	tests := []struct {
+		name string
+		copy interface{}
+	}{
+		{
+			name: "copy not defined",
+		},
+		{
+			name: "no destination key",
+			copy: map[string]interface{}{"source_key": "drafts/{path}"},
+		},
+		{
+			name: "same source and destination",
+			copy: map[string]interface{}{"source_key": "{path}", "destination_key": "{path}"},
+		},
+		{
+			name: "unknown metadata directive",
+			copy: map[string]interface{}{
+				"source_key":         "drafts/{path}",
+				"destination_key":    "published/{path}",
+				"metadata_directive": "merge",
+			},
+		},
+		{
+			name: "tags without replace directive",
+			copy: map[string]interface{}{
+				"source_key":      "drafts/{path}",
+				"destination_key": "published/{path}",
+				"tags":            map[string]interface{}{"classification": "public"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				l := mocks.NewMockLogger(ctrl)
+				l.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "copy" defined`),
+				).Times(1)
+
+				b := s3.BackendFactory(l, noopBackendFactory)
+				b(
+					&config.Backend{
+						URLPattern: "/some-endpoint",
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":    "bucket1",
+								"operation": "move",
+								"copy":      tt.copy,
+							},
+						},
+					},
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,153 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/luraproject/lura/v2/proxy"
+)
+
+const maxFilenameLength = 255
+
+var errInvalidDownload = errors.New(`aws s3: invalid "download" defined`)
+
+// DownloadOptions sets the headers telling the clients how to present the
+// objects sent in passthrough mode, i.e. as files to save.
+type DownloadOptions struct {
+	// Disposition is either attachment or inline.
+	Disposition string
+	// Filename is the template of the name of the file, defaulting to the
+	// last segment of the object key.
+	Filename string
+	// FilenameParam is the query param overriding the name of the file.
+	FilenameParam   string
+	ContentType     string
+	ContentLanguage string
+}
+
+// setDownloadHeaders adds the Content-Disposition, Content-Type and
+// Content-Language headers of the object to the given headers.
+func (b *backend) setDownloadHeaders(headers map[string][]string, obj *object, request *proxy.Request) {
+	d := b.opts.Download
+	if d == nil {
+		return
+	}
+
+	if d.ContentType != "" {
+		headers["Content-Type"] = []string{d.ContentType}
+	}
+
+	if d.ContentLanguage != "" {
+		headers["Content-Language"] = []string{d.ContentLanguage}
+	}
+
+	filename := ""
+	if d.FilenameParam != "" {
+		filename = sanitizeFilename(request.Query.Get(d.FilenameParam))
+	}
+	if filename == "" && d.Filename != "" {
+		filename = sanitizeFilename(expandKey(d.Filename, request))
+	}
+	if filename == "" {
+		filename = sanitizeFilename(obj.key)
+	}
+
+	headers["Content-Disposition"] = []string{contentDisposition(d.Disposition, filename)}
+}
+
+// sanitizeFilename keeps the last segment of the given name, without control
+// characters, quotes, backslashes or leading dots, so it can't be used to
+// write outside the download directory or to break the header.
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+
+	name = strings.Map(
+		func(r rune) rune {
+			if r == utf8.RuneError || r == '"' || r == '/' || unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, name,
+	)
+	name = strings.TrimLeft(strings.TrimSpace(name), ".")
+
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	return name
+}
+
+// contentDisposition formats the Content-Disposition header, adding the
+// RFC 5987 encoded filename when it has non ASCII characters.
+func contentDisposition(disposition, filename string) string {
+	if filename == "" {
+		return disposition
+	}
+
+	ascii := strings.Map(
+		func(r rune) rune {
+			if r > unicode.MaxASCII {
+				return '_'
+			}
+			return r
+		}, filename,
+	)
+	if ascii == filename {
+		return fmt.Sprintf(`%s; filename="%s"`, disposition, filename)
+	}
+
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, disposition, ascii, encodeRFC5987(filename))
+}
+
+func encodeRFC5987(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < utf8.RuneSelf && (unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte("!#$&+-.^_`|~", c) >= 0) {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+
+	return sb.String()
+}
+
+func getDownloadOptions(cfg map[string]interface{}) (*DownloadOptions, error) {
+	v, ok := cfg["download"]
+	if !ok {
+		return nil, nil
+	}
+
+	dc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errInvalidDownload
+	}
+
+	opts := &DownloadOptions{Disposition: "attachment"}
+	for name, dst := range map[string]*string{
+		"disposition":      &opts.Disposition,
+		"filename":         &opts.Filename,
+		"filename_param":   &opts.FilenameParam,
+		"content_type":     &opts.ContentType,
+		"content_language": &opts.ContentLanguage,
+	} {
+		if v, ok := dc[name]; ok {
+			if *dst, ok = v.(string); !ok {
+				return nil, errInvalidDownload
+			}
+		}
+	}
+
+	if opts.Disposition != "attachment" && opts.Disposition != "inline" {
+		return nil, errInvalidDownload
+	}
+
+	return opts, nil
+}
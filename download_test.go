@@ -0,0 +1,136 @@
+package s3_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/encoding"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_download(t *testing.T) {
+	tests := []struct {
+		name        string
+		download    map[string]interface{}
+		request     *proxy.Request
+		wantHeaders map[string][]string
+	}{
+		{
+			name:     "no filename configured, should use the last segment of the key",
+			download: map[string]interface{}{},
+			request:  &proxy.Request{Path: "/reports/2022/sales.csv"},
+			wantHeaders: map[string][]string{
+				"Content-Type":        {"text/csv"},
+				"Content-Disposition": {`attachment; filename="sales.csv"`},
+			},
+		},
+		{
+			name: "filename template, should expand the request params",
+			download: map[string]interface{}{
+				"disposition":      "inline",
+				"filename":         "sales-{year}.csv",
+				"content_type":     "text/csv; charset=utf-8",
+				"content_language": "en",
+			},
+			request: &proxy.Request{Path: "/reports/2022/sales.csv", Params: map[string]string{"Year": "2022"}},
+			wantHeaders: map[string][]string{
+				"Content-Type":        {"text/csv; charset=utf-8"},
+				"Content-Language":    {"en"},
+				"Content-Disposition": {`inline; filename="sales-2022.csv"`},
+			},
+		},
+		{
+			name:     "filename query param, should be sanitized",
+			download: map[string]interface{}{"filename_param": "name"},
+			request: &proxy.Request{
+				Path:  "/reports/2022/sales.csv",
+				Query: url.Values{"name": {"..\\..\\etc/..passwd\"\r\n.csv"}},
+			},
+			wantHeaders: map[string][]string{
+				"Content-Type":        {"text/csv"},
+				"Content-Disposition": {`attachment; filename="passwd.csv"`},
+			},
+		},
+		{
+			name:     "non ascii filename, should add the encoded filename",
+			download: map[string]interface{}{"filename_param": "name"},
+			request: &proxy.Request{
+				Path:  "/reports/2022/sales.csv",
+				Query: url.Values{"name": {"ventes été.csv"}},
+			},
+			wantHeaders: map[string][]string{
+				"Content-Type":        {"text/csv"},
+				"Content-Disposition": {`attachment; filename="ventes _t_.csv"; filename*=UTF-8''ventes%20%C3%A9t%C3%A9.csv`},
+			},
+		},
+		{
+			name:     "empty filename query param, should fall back to the key",
+			download: map[string]interface{}{"filename_param": "name"},
+			request: &proxy.Request{
+				Path:  "/reports/2022/sales.csv",
+				Query: url.Values{"name": {"../.."}},
+			},
+			wantHeaders: map[string][]string{
+				"Content-Type":        {"text/csv"},
+				"Content-Disposition": {`attachment; filename="sales.csv"`},
+			},
+		},
+		{
+			name:     "long filename, should be truncated",
+			download: map[string]interface{}{"filename_param": "name"},
+			request: &proxy.Request{
+				Path:  "/reports/2022/sales.csv",
+				Query: url.Values{"name": {strings.Repeat("a", 300)}},
+			},
+			wantHeaders: map[string][]string{
+				"Content-Type":        {"text/csv"},
+				"Content-Disposition": {`attachment; filename="` + strings.Repeat("a", 255) + `"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				out := objectOutput("day,total\n")
+				out.ContentType = aws.String("text/csv")
+				expectGetObject(cl, "reports/2022/sales.csv").Return(out, nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						Encoding: encoding.NOOP,
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":   "bucket1",
+								"download": tt.download,
+							},
+						},
+					},
+				)
+
+				got, err := p(context.Background(), tt.request)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.wantHeaders, got.Metadata.Headers)
+			},
+		)
+	}
+}
@@ -0,0 +1,144 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/PaesslerAG/gval"
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+var (
+	errInvalidExtract = errors.New(`aws s3: invalid "extract" defined`)
+	errNoMatch        = client.HTTPResponseError{Code: http.StatusNotFound, Msg: "aws s3: no element matched the extract expression"}
+)
+
+// extractPlaceholderPattern matches the {name} placeholders of an extract
+// expression, along with the quotes around them if any.
+var extractPlaceholderPattern = regexp.MustCompile(`'\{[^{}]+\}'|"\{[^{}]+\}"|\{[^{}]+\}`)
+
+// numberPattern matches the json numbers the unquoted placeholders of an
+// extract expression can be bound to.
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// compileExtract parses the extract expression once when it has no
+// placeholders, as it is the same for every request then.
+func compileExtract(expression string) gval.Evaluable {
+	if expression == "" || extractPlaceholderPattern.MatchString(expression) {
+		return nil
+	}
+
+	eval, err := jsonpath.New(expression)
+	if err != nil {
+		return nil
+	}
+
+	return eval
+}
+
+// extract evaluates the extract expression against the decoded object. Maps
+// become the response data, while lists are returned under the collection key
+// or, when ExtractFirst is set, reduced to their first element.
+func (b *backend) extract(ctx context.Context, data map[string]interface{}, request *proxy.Request) (map[string]interface{}, error) {
+	if b.opts.Extract == "" {
+		return data, nil
+	}
+
+	eval := b.extractor
+	if eval == nil {
+		expression, ok := bindExtract(b.opts.Extract, request)
+		if !ok {
+			return nil, errNoMatch
+		}
+
+		var err error
+		if eval, err = jsonpath.New(expression); err != nil {
+			return nil, err
+		}
+	}
+
+	v, err := eval(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if vs, ok := v.([]interface{}); ok && b.opts.ExtractFirst {
+		if len(vs) == 0 {
+			return nil, errNoMatch
+		}
+		v = vs[0]
+	}
+
+	switch res := v.(type) {
+	case map[string]interface{}:
+		return res, nil
+	case []interface{}:
+		return map[string]interface{}{"collection": res}, nil
+	case nil:
+		return nil, errNoMatch
+	default:
+		return map[string]interface{}{"value": res}, nil
+	}
+}
+
+// bindExtract replaces the {name} placeholders of the expression with the
+// value of the request param with that name, so the values can not alter the
+// expression. Quoted placeholders, i.e. '{name}', are bound as string
+// literals and unquoted ones as numbers, reporting false when the value is
+// not a number, as nothing can match it then.
+func bindExtract(expression string, request *proxy.Request) (string, bool) {
+	ok := true
+	bound := bindPlaceholders(
+		expression, func(name string, quoted bool) string {
+			v := param(request, name)
+			if quoted {
+				return strconv.Quote(v)
+			}
+
+			if !numberPattern.MatchString(v) {
+				ok = false
+				return "0"
+			}
+
+			return v
+		},
+	)
+
+	return bound, ok
+}
+
+func bindPlaceholders(expression string, bind func(name string, quoted bool) string) string {
+	return extractPlaceholderPattern.ReplaceAllStringFunc(
+		expression, func(m string) string {
+			if m[0] == '\'' || m[0] == '"' {
+				return bind(m[2:len(m)-2], true)
+			}
+
+			return bind(m[1:len(m)-1], false)
+		},
+	)
+}
+
+// validateExtract checks the syntax of the expression, binding its
+// placeholders to sample values.
+func validateExtract(expression string) error {
+	expression = bindPlaceholders(
+		expression, func(_ string, quoted bool) string {
+			if quoted {
+				return `""`
+			}
+			return "0"
+		},
+	)
+
+	if _, err := jsonpath.New(expression); err != nil {
+		return errInvalidExtract
+	}
+
+	return nil
+}
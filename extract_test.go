@@ -0,0 +1,225 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+const catalog = `{
+	"products": [
+		{"id": "1", "name": "first"},
+		{"id": "2", "name": "second"},
+		{"id": "it's", "name": "quoted"}
+	]
+}`
+
+func TestBackendFactoryWithClient_extract(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		params  map[string]string
+		wantErr assert.ErrorAssertionFunc
+		want    map[string]interface{}
+	}{
+		{
+			name: "filter by request param, should return the matching element",
+			config: map[string]interface{}{
+				"bucket":        "bucket1",
+				"extract":       "$.products[?(@.id == '{id}')]",
+				"extract_first": true,
+			},
+			params:  map[string]string{"Id": "2"},
+			wantErr: assert.NoError,
+			want:    map[string]interface{}{"id": "2", "name": "second"},
+		},
+		{
+			name: "param with quotes, should be bound as a literal",
+			config: map[string]interface{}{
+				"bucket":        "bucket1",
+				"extract":       "$.products[?(@.id == '{id}')]",
+				"extract_first": true,
+			},
+			params:  map[string]string{"Id": "it's"},
+			wantErr: assert.NoError,
+			want:    map[string]interface{}{"id": "it's", "name": "quoted"},
+		},
+		{
+			name: "param trying to alter the expression, should not match",
+			config: map[string]interface{}{
+				"bucket":        "bucket1",
+				"extract":       "$.products[?(@.id == '{id}')]",
+				"extract_first": true,
+			},
+			params:  map[string]string{"Id": "1' || @.id != '"},
+			wantErr: wantStatusCode(http.StatusNotFound),
+		},
+		{
+			name: "list result, should return it as a collection",
+			config: map[string]interface{}{
+				"bucket":  "bucket1",
+				"extract": "$.products[?(@.id != '{id}')].name",
+			},
+			params:  map[string]string{"Id": "2"},
+			wantErr: assert.NoError,
+			want:    map[string]interface{}{"collection": []interface{}{"first", "quoted"}},
+		},
+		{
+			name: "no matching element, should return not found",
+			config: map[string]interface{}{
+				"bucket":        "bucket1",
+				"extract":       "$.products[?(@.id == '{id}')]",
+				"extract_first": true,
+			},
+			params:  map[string]string{"Id": "3"},
+			wantErr: wantStatusCode(http.StatusNotFound),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				expectGetObject(cl, "catalog").Return(objectOutput(catalog), nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: tt.config}})
+
+				got, err := p(context.Background(), &proxy.Request{Path: "/catalog", Params: tt.params})
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				assert.Equal(t, tt.want, got.Data)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_extractNumber(t *testing.T) {
+	const orders = `{"orders": [{"id": 1, "total": 10.5}, {"id": 2, "total": 20}]}`
+
+	tests := []struct {
+		name    string
+		extract string
+		params  map[string]string
+		wantErr assert.ErrorAssertionFunc
+		want    map[string]interface{}
+	}{
+		{
+			name:    "unquoted placeholder, should be bound as a number",
+			extract: "$.orders[?(@.id == {id})]",
+			params:  map[string]string{"Id": "2"},
+			wantErr: assert.NoError,
+			want:    map[string]interface{}{"id": float64(2), "total": float64(20)},
+		},
+		{
+			name:    "decimal param, should be bound as a number",
+			extract: "$.orders[?(@.total == {total})]",
+			params:  map[string]string{"Total": "10.5"},
+			wantErr: assert.NoError,
+			want:    map[string]interface{}{"id": float64(1), "total": 10.5},
+		},
+		{
+			name:    "quoted placeholder, should not match numbers",
+			extract: "$.orders[?(@.id == '{id}')]",
+			params:  map[string]string{"Id": "2"},
+			wantErr: wantStatusCode(http.StatusNotFound),
+		},
+		{
+			name:    "param not a number, should not match",
+			extract: "$.orders[?(@.id == {id})]",
+			params:  map[string]string{"Id": "2 || @.id != 2"},
+			wantErr: wantStatusCode(http.StatusNotFound),
+		},
+		{
+			name:    "expression without placeholders, should be evaluated",
+			extract: "$.orders[?(@.id == 2)]",
+			wantErr: assert.NoError,
+			want:    map[string]interface{}{"id": float64(2), "total": float64(20)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				expectGetObject(cl, "orders").Return(objectOutput(orders), nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":        "bucket1",
+								"cache_ttl":     "1m",
+								"extract":       tt.extract,
+								"extract_first": true,
+							},
+						},
+					},
+				)
+
+				// the second request evaluates the expression against the cached
+				// object again.
+				for i := 0; i < 2; i++ {
+					got, err := p(context.Background(), &proxy.Request{Path: "/orders", Params: tt.params})
+					if !tt.wantErr(t, err) || err != nil {
+						return
+					}
+
+					assert.Equal(t, tt.want, got.Data)
+				}
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_invalidExtract(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	l := mocks.NewMockLogger(ctrl)
+	l.EXPECT().Error("[BACKEND: /catalog][S3]", errors.New(`aws s3: invalid "extract" defined`))
+
+	called := false
+	b := s3.BackendFactoryWithClient(
+		l, func(remote *config.Backend) proxy.Proxy {
+			called = true
+			return proxy.NoopProxy
+		},
+		func(opts *s3.Options) s3.ObjectGetter {
+			return nil
+		},
+	)
+	b(
+		&config.Backend{
+			URLPattern: "/catalog",
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":  "bucket1",
+					"extract": "$.products[?(@.id == ",
+				},
+			},
+		},
+	)
+
+	assert.True(t, called)
+}
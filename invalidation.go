@@ -0,0 +1,251 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+const (
+	defaultInvalidationWaitTime    = 20 * time.Second
+	defaultInvalidationMaxMessages = 10
+
+	// receiveRetryDelay is the time waited before polling the queue again
+	// after a failure, so an unreachable queue is not polled in a loop.
+	receiveRetryDelay = time.Second
+
+	objectCreatedEvent = "ObjectCreated:"
+)
+
+var errInvalidInvalidation = errors.New(`aws s3: invalid "invalidation" defined`)
+
+// InvalidationOptions is the SQS queue the backend receives the event
+// notifications of its buckets from, to drop the copies of the modified
+// objects kept in memory. The messages are deleted once received, so every
+// gateway instance and every backend needs a queue of its own, i.e. one SNS
+// topic fanning out the notifications to all of them.
+type InvalidationOptions struct {
+	QueueURL string
+	// AWSConfig is the config of the SQS client, inheriting the credentials
+	// and region of the backend.
+	AWSConfig   aws.Config
+	WaitTime    time.Duration
+	MaxMessages int
+	// Refresh fetches the created objects again instead of waiting for the
+	// next request to do it.
+	Refresh bool
+}
+
+// queueClient receives and deletes the messages of an SQS queue.
+type queueClient interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
+// eventNotification is the body of the s3 event notifications, also sent
+// wrapped in the Message field of an SNS notification when the queue is
+// subscribed to a topic.
+type eventNotification struct {
+	Message string `json:"Message"`
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// subscribe long polls the invalidation queue in the background until the
+// context is done.
+func (b *backend) subscribe(ctx context.Context) {
+	inv := b.opts.Invalidation
+	if inv == nil {
+		return
+	}
+
+	q := sqs.NewFromConfig(inv.AWSConfig)
+	go func() {
+		for ctx.Err() == nil {
+			if err := b.receiveNotifications(ctx, q); err != nil && ctx.Err() == nil {
+				b.logger.Error(b.logPrefix, "receiving s3 event notifications failed:", err)
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(receiveRetryDelay):
+				}
+			}
+		}
+	}()
+}
+
+// receiveNotifications handles a batch of messages of the invalidation
+// queue, deleting them once handled.
+func (b *backend) receiveNotifications(ctx context.Context, q queueClient) error {
+	inv := b.opts.Invalidation
+	out, err := q.ReceiveMessage(
+		ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(inv.QueueURL),
+			MaxNumberOfMessages: int32(inv.MaxMessages),
+			WaitTimeSeconds:     int32(inv.WaitTime / time.Second),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range out.Messages {
+		if err := b.handleNotification(ctx, aws.ToString(m.Body)); err != nil {
+			b.logger.Error(b.logPrefix, "invalid s3 event notification", aws.ToString(m.MessageId)+":", err)
+		}
+
+		if _, err := q.DeleteMessage(
+			ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(inv.QueueURL),
+				ReceiptHandle: m.ReceiptHandle,
+			},
+		); err != nil {
+			b.logger.Error(b.logPrefix, "deleting s3 event notification", aws.ToString(m.MessageId), "failed:", err)
+		}
+	}
+
+	return nil
+}
+
+// handleNotification invalidates the objects of the event notification that
+// belong to the buckets of the backend, ignoring the rest.
+func (b *backend) handleNotification(ctx context.Context, body string) error {
+	n := eventNotification{}
+	if err := json.Unmarshal([]byte(body), &n); err != nil {
+		return err
+	}
+
+	if n.Message != "" && len(n.Records) == 0 {
+		return b.handleNotification(ctx, n.Message)
+	}
+
+	for _, r := range n.Records {
+		k, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return err
+		}
+
+		tb, ok := b.forBucket(r.S3.Bucket.Name)
+		if !ok {
+			continue
+		}
+
+		preloaded := tb.preloaded.Has(k)
+		tb.invalidate(tb.targets[0].bucket, k)
+		b.logger.Debug(b.logPrefix, "invalidated", r.S3.Bucket.Name+"/"+k, "after", r.EventName)
+
+		if !strings.HasPrefix(r.EventName, objectCreatedEvent) || !(b.opts.Invalidation.Refresh || preloaded) {
+			continue
+		}
+
+		if _, _, err := tb.fetch(ctx, k); err != nil {
+			b.logger.Error(b.logPrefix, "refreshing", r.S3.Bucket.Name+"/"+k, "failed:", err)
+		}
+	}
+
+	return nil
+}
+
+// forBucket returns the backend serving the objects of the given bucket,
+// either a replica or a tenant one.
+func (b *backend) forBucket(bucket string) (*backend, bool) {
+	for _, t := range b.targets {
+		if t.bucket == bucket {
+			return b, true
+		}
+	}
+
+	for _, targets := range b.tenants {
+		if targets[0].bucket == bucket {
+			tb := *b
+			tb.targets = targets
+			return &tb, true
+		}
+	}
+
+	return nil, false
+}
+
+func getInvalidation(cfg map[string]interface{}, opts *Options) (*InvalidationOptions, error) {
+	v, ok := cfg["invalidation"]
+	if !ok {
+		return nil, nil
+	}
+
+	ic, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errInvalidInvalidation
+	}
+
+	// without any copy kept in memory there is nothing to invalidate, and the
+	// messages would just be drained from the queue.
+	if opts.CacheTTL <= 0 && opts.ServeStaleOnError <= 0 && len(opts.Preload) == 0 {
+		return nil, errInvalidInvalidation
+	}
+
+	// the queue is polled with the static credentials of the backend, as
+	// otherwise the requests would be sent unsigned and rejected forever.
+	if opts.AWSConfig.Credentials == nil {
+		return nil, errInvalidInvalidation
+	}
+
+	inv := &InvalidationOptions{
+		AWSConfig:   opts.AWSConfig.Copy(),
+		WaitTime:    defaultInvalidationWaitTime,
+		MaxMessages: defaultInvalidationMaxMessages,
+	}
+	inv.AWSConfig.EndpointResolverWithOptions = nil
+
+	if inv.QueueURL, _ = ic["queue_url"].(string); inv.QueueURL == "" {
+		return nil, errInvalidInvalidation
+	}
+
+	if region, ok := ic["region"].(string); ok {
+		inv.AWSConfig.Region = region
+	}
+
+	if endpoint, ok := ic["endpoint"].(string); ok && endpoint != "" {
+		inv.AWSConfig.EndpointResolverWithOptions = endpointResolver(endpoint)
+	}
+
+	if _, ok := ic["wait_time"]; ok {
+		// an empty queue would be polled non-stop without waiting at least a
+		// second for the messages.
+		d, err := getDuration(ic, "wait_time")
+		if err != nil || d < time.Second || d%time.Second != 0 || d > defaultInvalidationWaitTime {
+			return nil, errInvalidInvalidation
+		}
+		inv.WaitTime = d
+	}
+
+	if _, ok := ic["max_messages"]; ok {
+		n, ok := getInt(ic, "max_messages")
+		if !ok || n < 1 || n > defaultInvalidationMaxMessages {
+			return nil, errInvalidInvalidation
+		}
+		inv.MaxMessages = n
+	}
+
+	if v, ok := ic["refresh"]; ok {
+		if inv.Refresh, ok = v.(bool); !ok {
+			return nil, errInvalidInvalidation
+		}
+	}
+
+	return inv, nil
+}
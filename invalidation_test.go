@@ -0,0 +1,247 @@
+package s3_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClientContext_invalidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		refresh      bool
+		preload      bool
+		message      string
+		wantFetches  int32
+		wantResponse string
+	}{
+		{
+			name:         "object removed, should evict the cached object",
+			message:      eventMessage("ObjectRemoved:Delete", "bucket1", "config.json"),
+			wantFetches:  2,
+			wantResponse: "v2",
+		},
+		{
+			name:         "notification from sns, should evict the cached object",
+			message:      snsMessage(eventMessage("ObjectCreated:Put", "bucket1", "config.json")),
+			wantFetches:  2,
+			wantResponse: "v2",
+		},
+		{
+			name:         "object created with refresh, should fetch the object again",
+			refresh:      true,
+			message:      eventMessage("ObjectCreated:Put", "bucket1", "config.json"),
+			wantFetches:  2,
+			wantResponse: "v2",
+		},
+		{
+			name:         "preloaded object created without refresh, should fetch the object again",
+			preload:      true,
+			message:      eventMessage("ObjectCreated:Put", "bucket1", "config.json"),
+			wantFetches:  2,
+			wantResponse: "v2",
+		},
+		{
+			name:         "object of a replica, should evict the cached object",
+			message:      eventMessage("ObjectCreated:Copy", "bucket2", "config.json"),
+			wantFetches:  2,
+			wantResponse: "v2",
+		},
+		{
+			name:         "object of another bucket, should be ignored",
+			message:      eventMessage("ObjectCreated:Put", "other", "config.json"),
+			wantFetches:  1,
+			wantResponse: "v1",
+		},
+		{
+			name:         "invalid message, should be deleted",
+			message:      `{"Records": "invalid"}`,
+			wantFetches:  1,
+			wantResponse: "v1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				queue := newQueueStandIn()
+				defer queue.Close()
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				var fetches int32
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				expectGetObject(cl, "config.json").AnyTimes().DoAndReturn(
+					func(_ context.Context, _ *awsS3.GetObjectInput, _ ...func(*awsS3.Options)) (*awsS3.GetObjectOutput, error) {
+						n := atomic.AddInt32(&fetches, 1)
+						return objectOutput(fmt.Sprintf(`{"version": "v%d"}`, n)), nil
+					},
+				)
+
+				b := s3.BackendFactoryWithClientContext(
+					ctx, logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				cfg := map[string]interface{}{
+					"bucket":    "bucket1",
+					"region":    "us-east-1",
+					"key":       "key",
+					"secret":    "secret",
+					"cache_ttl": "1h",
+					"replicas":  []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+					"invalidation": map[string]interface{}{
+						"queue_url": queue.URL + "/123456789012/events",
+						"endpoint":  queue.URL,
+						"wait_time": "1s",
+						"refresh":   tt.refresh,
+					},
+				}
+				if tt.preload {
+					cfg["preload"] = []interface{}{"config.json"}
+				}
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: cfg}})
+
+				get := func() string {
+					got, err := p(context.Background(), &proxy.Request{Path: "/config.json"})
+					if !assert.NoError(t, err) {
+						return ""
+					}
+					return got.Data["version"].(string)
+				}
+
+				assert.Equal(t, "v1", get())
+
+				queue.Send(tt.message)
+				assert.Eventually(t, func() bool { return queue.Deleted() == 1 }, time.Second, 5*time.Millisecond)
+				if tt.refresh || tt.preload {
+					assert.Eventually(
+						t, func() bool { return atomic.LoadInt32(&fetches) == tt.wantFetches }, time.Second, 5*time.Millisecond,
+					)
+				}
+
+				assert.Equal(t, tt.wantResponse, get())
+				assert.Equal(t, tt.wantResponse, get())
+				assert.Equal(t, tt.wantFetches, atomic.LoadInt32(&fetches))
+			},
+		)
+	}
+}
+
+func eventMessage(event, bucket, key string) string {
+	return fmt.Sprintf(
+		`{"Records": [{"eventName": %q, "s3": {"bucket": {"name": %q}, "object": {"key": %q}}}]}`,
+		event, bucket, key,
+	)
+}
+
+func snsMessage(message string) string {
+	body, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": message})
+	return string(body)
+}
+
+// queueStandIn is a local stand-in of an SQS queue, answering the
+// ReceiveMessage and DeleteMessage actions of the query protocol.
+type queueStandIn struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []string
+	sent     int
+	deleted  int
+}
+
+func newQueueStandIn() *queueStandIn {
+	q := &queueStandIn{}
+	q.Server = httptest.NewServer(http.HandlerFunc(q.serve))
+	return q
+}
+
+func (q *queueStandIn) Send(body string) {
+	q.mu.Lock()
+	q.messages = append(q.messages, body)
+	q.mu.Unlock()
+}
+
+func (q *queueStandIn) Deleted() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deleted
+}
+
+func (q *queueStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	switch r.Form.Get("Action") {
+	case "ReceiveMessage":
+		q.receive(w, r)
+	case "DeleteMessage":
+		q.mu.Lock()
+		q.deleted++
+		q.mu.Unlock()
+		fmt.Fprint(w, `<DeleteMessageResponse><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></DeleteMessageResponse>`)
+	default:
+		http.Error(w, "unsupported action", http.StatusBadRequest)
+	}
+}
+
+func (q *queueStandIn) receive(w http.ResponseWriter, r *http.Request) {
+	wait, _ := strconv.Atoi(r.Form.Get("WaitTimeSeconds"))
+	deadline := time.Now().Add(time.Duration(wait) * time.Second)
+
+	var messages []string
+	for {
+		q.mu.Lock()
+		messages, q.messages = q.messages, nil
+		q.mu.Unlock()
+
+		if len(messages) > 0 || time.Now().After(deadline) {
+			break
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	fmt.Fprint(w, `<ReceiveMessageResponse><ReceiveMessageResult>`)
+	for _, m := range messages {
+		q.mu.Lock()
+		q.sent++
+		id := q.sent
+		q.mu.Unlock()
+
+		sum := md5.Sum([]byte(m))
+		fmt.Fprintf(
+			w, `<Message><MessageId>%d</MessageId><ReceiptHandle>handle-%d</ReceiptHandle><MD5OfBody>%s</MD5OfBody><Body>%s</Body></Message>`,
+			id, id, hex.EncodeToString(sum[:]), html.EscapeString(m),
+		)
+	}
+	fmt.Fprint(w, `</ReceiveMessageResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></ReceiveMessageResponse>`)
+}
@@ -0,0 +1,101 @@
+package s3
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/luraproject/lura/v2/proxy"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// headerPlaceholderPrefix marks the placeholders replaced by a request header.
+const headerPlaceholderPrefix = "header."
+
+// candidate is an object key to try when serving a request, along with the
+// status code of the response when the object is found under it.
+type candidate struct {
+	key        string
+	statusCode int
+}
+
+// candidateKeys returns the keys to try in order for the given request path,
+// moving to the next one while the previous does not exist in the bucket.
+func candidateKeys(opts *Options, request *proxy.Request) []candidate {
+	p := strings.TrimPrefix(request.Path, "/")
+	k := p
+
+	switch {
+	case opts.IndexDocument != "" && (p == "" || strings.HasSuffix(p, "/")):
+		k += opts.IndexDocument
+	case len(opts.PathExtension) > 0:
+		k += "." + opts.PathExtension
+	}
+
+	cs := []candidate{{key: k, statusCode: http.StatusOK}}
+
+	if opts.TryHTMLExtension && path.Ext(k) == "" && !strings.HasSuffix(k, "/") {
+		cs = append(cs, candidate{key: k + ".html", statusCode: http.StatusOK})
+	}
+
+	fallbackStatusCode := http.StatusOK
+	if opts.FallbackStatusCode > 0 {
+		fallbackStatusCode = opts.FallbackStatusCode
+	}
+
+	for _, fk := range opts.FallbackKeys {
+		cs = append(cs, candidate{key: expandKey(fk, request), statusCode: fallbackStatusCode})
+	}
+
+	if opts.ErrorDocument != "" {
+		cs = append(cs, candidate{key: opts.ErrorDocument, statusCode: http.StatusNotFound})
+	}
+
+	return cs
+}
+
+// expandKey builds an object key from the given template, replacing the {path}
+// placeholder with the request path, the {header.Name} placeholders with the
+// value of the request header and any other {name} placeholder with the value
+// of the request param with that name.
+func expandKey(tmpl string, request *proxy.Request) string {
+	k := placeholderPattern.ReplaceAllStringFunc(
+		tmpl, func(m string) string {
+			name := m[1 : len(m)-1]
+			if name == "path" {
+				return strings.TrimPrefix(request.Path, "/")
+			}
+
+			if h := strings.TrimPrefix(name, headerPlaceholderPrefix); h != name {
+				return http.Header(request.Headers).Get(h)
+			}
+
+			return param(request, name)
+		},
+	)
+
+	return strings.TrimPrefix(k, "/")
+}
+
+// param returns the value of the request param with the given name.
+func param(request *proxy.Request, name string) string {
+	v, _ := lookupParam(request, name)
+	return v
+}
+
+// lookupParam looks up the request param with the given name. Lura capitalizes
+// the first letter of the param names, so both forms are looked up.
+func lookupParam(request *proxy.Request, name string) (string, bool) {
+	if v, ok := request.Params[name]; ok {
+		return v, true
+	}
+
+	if name == "" {
+		return "", false
+	}
+
+	v, ok := request.Params[strings.ToUpper(name[:1])+name[1:]]
+	return v, ok
+}
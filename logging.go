@@ -0,0 +1,102 @@
+package s3
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+	"github.com/luraproject/lura/v2/logging"
+)
+
+var errInvalidLogLevel = errors.New(`aws s3: invalid "log_level" or "log_sampling" defined`)
+
+// logFuncs maps the supported log levels to the matching logger method.
+var logFuncs = map[string]func(logging.Logger, ...interface{}){
+	"debug":    logging.Logger.Debug,
+	"info":     logging.Logger.Info,
+	"warning":  logging.Logger.Warning,
+	"error":    logging.Logger.Error,
+	"critical": logging.Logger.Critical,
+}
+
+// logOperation writes a structured log line for an s3 operation when request
+// logging is enabled. Failures are logged at error level, while successful
+// operations and missing keys are logged at the configured level, sampled.
+func (b *backend) logOperation(
+	operation, bucket, k string,
+	start time.Time,
+	metadata middleware.Metadata,
+	err error,
+) {
+	if b.opts.LogLevel == "" {
+		return
+	}
+
+	log := logFuncs[b.opts.LogLevel]
+	if err != nil && !isNotFound(err) {
+		log = logging.Logger.Error
+	} else if b.opts.LogSampling < 1 && rand.Float64() >= b.opts.LogSampling {
+		return
+	}
+
+	requestID, hostID := requestIDs(metadata, err)
+	fields := []string{
+		logField("operation", operation),
+		logField("bucket", bucket),
+		logField("key", k),
+		logField("status", status(err)),
+		logField("duration", time.Since(start).Round(time.Microsecond).String()),
+		logField("request_id", requestID),
+		logField("host_id", hostID),
+	}
+	if err != nil {
+		fields = append(fields, logField("error", err.Error()))
+	}
+
+	log(b.logger, b.logPrefix, strings.Join(fields, " "))
+}
+
+// logField formats a key=value pair, quoting the value when needed. Values
+// with non-printable characters, i.e. keys with decoded line breaks, are
+// quoted so they can't forge other log lines.
+func logField(name, value string) string {
+	if value == "" || strings.ContainsAny(value, " \"=") || strings.IndexFunc(value, isNotPrint) >= 0 {
+		value = strconv.Quote(value)
+	}
+
+	return name + "=" + value
+}
+
+func isNotPrint(r rune) bool {
+	return !strconv.IsPrint(r)
+}
+
+func getLogOptions(cfg map[string]interface{}, opts *Options) error {
+	level, ok := cfg["log_level"].(string)
+	if !ok {
+		return nil
+	}
+
+	level = strings.ToLower(level)
+	if _, ok := logFuncs[level]; !ok {
+		return errInvalidLogLevel
+	}
+	opts.LogLevel = level
+	opts.LogSampling = 1
+
+	switch v := cfg["log_sampling"].(type) {
+	case nil:
+	case float64:
+		if v < 0 || v > 1 {
+			return errInvalidLogLevel
+		}
+		opts.LogSampling = v
+	default:
+		return errInvalidLogLevel
+	}
+
+	return nil
+}
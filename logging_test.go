@@ -0,0 +1,220 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactory_logging(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("x-amz-request-id", "request-id")
+				w.Header().Set("x-amz-id-2", "host-id")
+				if r.URL.Path == "/bucket1/forbidden" {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"property1": "value1"}`))
+			},
+		),
+	)
+	defer srv.Close()
+
+	ctrl := gomock.NewController(t)
+	l := mocks.NewMockLogger(ctrl)
+
+	var debug, errs []string
+	l.EXPECT().Debug("[BACKEND: /{path}][S3]", gomock.Any()).Times(2).DoAndReturn(
+		func(v ...interface{}) { debug = append(debug, v[1].(string)) },
+	)
+	l.EXPECT().Error("[BACKEND: /{path}][S3]", gomock.Any()).Times(1).DoAndReturn(
+		func(v ...interface{}) { errs = append(errs, v[1].(string)) },
+	)
+
+	b := s3.BackendFactory(l, noopBackendFactory)
+	p := b(
+		&config.Backend{
+			URLPattern: "/{path}",
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":    "bucket1",
+					"region":    "eu-west-1",
+					"endpoint":  srv.URL,
+					"log_level": "debug",
+				},
+			},
+		},
+	)
+
+	_, err := p(context.Background(), &proxy.Request{Path: "/sample"})
+	assert.NoError(t, err)
+	_, err = p(context.Background(), &proxy.Request{Path: "/forbidden"})
+	assert.Error(t, err)
+	_, err = p(context.Background(), &proxy.Request{Path: "/sample\nforged"})
+	assert.NoError(t, err)
+
+	if assert.Len(t, debug, 2) {
+		assert.Regexp(
+			t,
+			`^operation=GetObject bucket=bucket1 key=sample status=ok duration=\S+ request_id=request-id host_id=host-id$`,
+			debug[0],
+		)
+		assert.Regexp(
+			t,
+			`^operation=GetObject bucket=bucket1 key="sample\\nforged" status=ok duration=\S+ request_id=request-id host_id=host-id$`,
+			debug[1],
+		)
+	}
+	if assert.Len(t, errs, 1) {
+		assert.Regexp(
+			t,
+			`^operation=GetObject bucket=bucket1 key=forbidden status=AccessDenied duration=\S+ request_id=request-id host_id=host-id error=".*Access Denied"$`,
+			errs[0],
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_logging(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   map[string]interface{}
+		setup func(logger *mocks.MockLogger, client *mocks.MockObjectGetter)
+		paths []string
+	}{
+		{
+			name: "log level not defined, should not log",
+			cfg:  map[string]interface{}{},
+			setup: func(logger *mocks.MockLogger, client *mocks.MockObjectGetter) {
+				expectGetObject(client, "missing").Times(1).Return(nil, &types.NoSuchKey{})
+			},
+			paths: []string{"/missing"},
+		},
+		{
+			name: "missing key, should log at the configured level",
+			cfg: map[string]interface{}{
+				"log_level": "info",
+			},
+			setup: func(logger *mocks.MockLogger, client *mocks.MockObjectGetter) {
+				expectGetObject(client, "missing").Times(1).Return(nil, &types.NoSuchKey{})
+				logger.EXPECT().Info(
+					"[BACKEND: /{path}][S3]",
+					gomock.Any(),
+				).Times(1)
+			},
+			paths: []string{"/missing"},
+		},
+		{
+			name: "sampling disabled, should only log failures",
+			cfg: map[string]interface{}{
+				"log_level":    "info",
+				"log_sampling": 0.0,
+			},
+			setup: func(logger *mocks.MockLogger, client *mocks.MockObjectGetter) {
+				expectGetObject(client, "sample").Times(1).Return(objectOutput(`{}`), nil)
+				expectGetObject(client, "failing").Times(1).Return(nil, errors.New("connection reset"))
+				logger.EXPECT().Error(
+					"[BACKEND: /{path}][S3]",
+					gomock.Any(),
+				).Times(1)
+			},
+			paths: []string{"/sample", "/failing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				l := mocks.NewMockLogger(ctrl)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				tt.setup(l, cl)
+
+				cfg := map[string]interface{}{"bucket": "bucket1"}
+				for k, v := range tt.cfg {
+					cfg[k] = v
+				}
+
+				b := s3.BackendFactoryWithClient(
+					l, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						URLPattern:  "/{path}",
+						ExtraConfig: map[string]interface{}{s3.Namespace: cfg},
+					},
+				)
+
+				for _, path := range tt.paths {
+					_, _ = p(context.Background(), &proxy.Request{Path: path})
+				}
+			},
+		)
+	}
+}
+
+func TestBackendFactory_selectLogging(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("x-amz-request-id", "request-id")
+				w.Header().Set("x-amz-id-2", "host-id")
+				w.WriteHeader(http.StatusOK)
+				writeEvent(t, w, "Records", []byte(`{"id":"1"}`+"\n"))
+				writeEvent(t, w, "End", nil)
+			},
+		),
+	)
+	defer srv.Close()
+
+	ctrl := gomock.NewController(t)
+	l := mocks.NewMockLogger(ctrl)
+
+	var debug []string
+	l.EXPECT().Debug("[BACKEND: /{path}][S3]", gomock.Any()).Times(1).DoAndReturn(
+		func(v ...interface{}) { debug = append(debug, v[1].(string)) },
+	)
+
+	b := s3.BackendFactory(l, noopBackendFactory)
+	p := b(
+		&config.Backend{
+			URLPattern: "/{path}",
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":    "bucket1",
+					"region":    "eu-west-1",
+					"endpoint":  srv.URL,
+					"log_level": "debug",
+					"select": map[string]interface{}{
+						"expression": "SELECT * FROM S3Object s",
+					},
+				},
+			},
+		},
+	)
+
+	_, err := p(context.Background(), &proxy.Request{Path: "/customers"})
+	assert.NoError(t, err)
+
+	if assert.Len(t, debug, 1) {
+		assert.Regexp(
+			t,
+			`^operation=SelectObjectContent bucket=bucket1 key=customers status=ok duration=\S+ request_id=request-id host_id=host-id$`,
+			debug[0],
+		)
+	}
+}
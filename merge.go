@@ -0,0 +1,155 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/luraproject/lura/v2/proxy"
+)
+
+const (
+	// MergeOverlay deep merges the objects, the latter keys overriding the former ones.
+	MergeOverlay = "overlay"
+	// MergeNamespace nests each object under its group name.
+	MergeNamespace = "namespace"
+)
+
+// ObjectKey is one of the objects merged into a single response. The key may
+// contain {path} and request param placeholders.
+type ObjectKey struct {
+	Key   string
+	Group string
+}
+
+// merge fetches all the configured keys concurrently and combines them into a
+// single response following the merge strategy.
+func (b *backend) merge(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	type result struct {
+		data  map[string]interface{}
+		stale bool
+		err   error
+	}
+
+	results := make([]result, len(b.opts.Keys))
+	wg := sync.WaitGroup{}
+	for i, key := range b.opts.Keys {
+		wg.Add(1)
+		go func(i int, k string) {
+			defer wg.Done()
+
+			obj, stale, err := b.fetch(ctx, k)
+			if err != nil {
+				results[i] = result{err: err}
+				return
+			}
+
+			data, err := b.decode(ctx, obj)
+			results[i] = result{data: data, stale: stale, err: err}
+		}(i, expandKey(key.Key, request))
+	}
+	wg.Wait()
+
+	data := map[string]interface{}{}
+	stale := false
+	for i, r := range results {
+		if r.err != nil {
+			if b.opts.SkipMissing && isNotFound(r.err) {
+				continue
+			}
+
+			return nil, r.err
+		}
+
+		stale = stale || r.stale
+
+		if b.opts.MergeStrategy == MergeNamespace {
+			data[b.opts.Keys[i].Group] = r.data
+			continue
+		}
+
+		deepMerge(data, r.data)
+	}
+
+	data, err := b.extract(ctx, data, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response := b.format(
+		ctx, proxy.Response{
+			Data:       data,
+			IsComplete: !stale,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: http.StatusOK,
+			},
+		},
+	)
+
+	if stale {
+		response.Metadata.Headers["Warning"] = []string{staleWarning}
+	}
+
+	return response, nil
+}
+
+// deepMerge copies the src values into dst, merging the nested objects
+// present in both instead of replacing them.
+func deepMerge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+
+		dstMap, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dst[k] = srcMap
+			continue
+		}
+
+		deepMerge(dstMap, srcMap)
+	}
+}
+
+// getObjectKeys parses the list of keys to merge, defined either as plain
+// strings or as objects with a "key" and an optional "group".
+func getObjectKeys(cfg map[string]interface{}) ([]ObjectKey, error) {
+	v, ok := cfg["keys"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	vs, ok := v.([]interface{})
+	if !ok || len(vs) == 0 {
+		return nil, errInvalidKeys
+	}
+
+	keys := make([]ObjectKey, 0, len(vs))
+	for _, v := range vs {
+		switch kv := v.(type) {
+		case string:
+			if kv == "" {
+				return nil, errInvalidKeys
+			}
+			keys = append(keys, ObjectKey{Key: kv, Group: kv})
+		case map[string]interface{}:
+			k, ok := kv["key"].(string)
+			if !ok || k == "" {
+				return nil, errInvalidKeys
+			}
+
+			group, ok := kv["group"].(string)
+			if !ok || group == "" {
+				group = k
+			}
+			keys = append(keys, ObjectKey{Key: k, Group: group})
+		default:
+			return nil, errInvalidKeys
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,117 @@
+package s3_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_merge(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		setup   func(client *mocks.MockObjectGetter)
+		wantErr assert.ErrorAssertionFunc
+		want    map[string]interface{}
+	}{
+		{
+			name: "overlay strategy, should deep merge the objects in order",
+			config: map[string]interface{}{
+				"bucket": "bucket1",
+				"keys":   []interface{}{"common.json", "locale/{lang}.json"},
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "common.json").
+					Return(objectOutput(`{"title": "Title", "labels": {"ok": "OK", "cancel": "Cancel"}}`), nil)
+				expectGetObject(client, "locale/es.json").
+					Return(objectOutput(`{"labels": {"ok": "Vale"}}`), nil)
+			},
+			wantErr: assert.NoError,
+			want: map[string]interface{}{
+				"title":  "Title",
+				"labels": map[string]interface{}{"ok": "Vale", "cancel": "Cancel"},
+			},
+		},
+		{
+			name: "namespace strategy, should nest each object under its group",
+			config: map[string]interface{}{
+				"bucket":         "bucket1",
+				"merge_strategy": "namespace",
+				"keys": []interface{}{
+					map[string]interface{}{"key": "common.json", "group": "common"},
+					map[string]interface{}{"key": "locale/{lang}.json", "group": "locale"},
+				},
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "common.json").Return(objectOutput(`{"title": "Title"}`), nil)
+				expectGetObject(client, "locale/es.json").Return(objectOutput(`{"ok": "Vale"}`), nil)
+			},
+			wantErr: assert.NoError,
+			want: map[string]interface{}{
+				"common": map[string]interface{}{"title": "Title"},
+				"locale": map[string]interface{}{"ok": "Vale"},
+			},
+		},
+		{
+			name: "missing object skipped, should merge the remaining ones",
+			config: map[string]interface{}{
+				"bucket":       "bucket1",
+				"skip_missing": true,
+				"keys":         []interface{}{"common.json", "locale/{lang}.json"},
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "common.json").Return(objectOutput(`{"title": "Title"}`), nil)
+				expectGetObject(client, "locale/es.json").Return(nil, &types.NoSuchKey{})
+			},
+			wantErr: assert.NoError,
+			want:    map[string]interface{}{"title": "Title"},
+		},
+		{
+			name: "missing object not skipped, should return error",
+			config: map[string]interface{}{
+				"bucket": "bucket1",
+				"keys":   []interface{}{"common.json", "locale/{lang}.json"},
+			},
+			setup: func(client *mocks.MockObjectGetter) {
+				expectGetObject(client, "common.json").Return(objectOutput(`{"title": "Title"}`), nil)
+				expectGetObject(client, "locale/es.json").Return(nil, &types.NoSuchKey{})
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.IsType(t, &types.NoSuchKey{}, err, i...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				tt.setup(cl)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: tt.config}})
+
+				got, err := p(context.Background(), &proxy.Request{Path: "/sample", Params: map[string]string{"Lang": "es"}})
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				assert.Equal(t, tt.want, got.Data)
+				assert.True(t, got.IsComplete)
+			},
+		)
+	}
+}
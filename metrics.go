@@ -0,0 +1,129 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	cacheHit       = "hit"
+	cacheMiss      = "miss"
+	cacheStale     = "stale"
+	cachePreloaded = "preloaded"
+)
+
+// metrics records the s3 operations issued by a backend with the instruments
+// of the global OpenTelemetry meter provider, so they are exported along with
+// the rest of the gateway metrics.
+type metrics struct {
+	attrs          []attribute.KeyValue
+	duration       metric.Float64Histogram
+	bytesRead      metric.Int64Counter
+	decodeFailures metric.Int64Counter
+	cacheLookups   metric.Int64Counter
+	preloads       metric.Int64Counter
+}
+
+func newMetrics(urlPattern string) *metrics {
+	meter := otel.GetMeterProvider().Meter(Namespace)
+
+	// the instruments fall back to no-op ones when they can not be created, so
+	// the errors are ignored.
+	duration, _ := meter.Float64Histogram(
+		"s3.backend.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of the s3 operations issued by the backend."),
+	)
+	bytesRead, _ := meter.Int64Counter(
+		"s3.backend.bytes_read",
+		metric.WithUnit("By"),
+		metric.WithDescription("Bytes read from the s3 objects."),
+	)
+	decodeFailures, _ := meter.Int64Counter(
+		"s3.backend.decode_failures",
+		metric.WithDescription("Objects that could not be decoded."),
+	)
+	cacheLookups, _ := meter.Int64Counter(
+		"s3.backend.cache_lookups",
+		metric.WithDescription("Lookups of the object cache, by result."),
+	)
+
+	preloads, _ := meter.Int64Counter(
+		"s3.backend.preload_refreshes",
+		metric.WithDescription("Refreshes of the preloaded objects, by status."),
+	)
+
+	return &metrics{
+		attrs:          []attribute.KeyValue{attribute.String("url_pattern", urlPattern)},
+		duration:       duration,
+		bytesRead:      bytesRead,
+		decodeFailures: decodeFailures,
+		cacheLookups:   cacheLookups,
+		preloads:       preloads,
+	}
+}
+
+// observe records an s3 operation started at the given time.
+func (m *metrics) observe(ctx context.Context, operation, bucket string, start time.Time, bytesRead int, err error) {
+	opt := m.with(
+		attribute.String("bucket", bucket),
+		attribute.String("operation", operation),
+		attribute.String("status", status(err)),
+	)
+
+	m.duration.Record(ctx, time.Since(start).Seconds(), opt)
+	if bytesRead > 0 {
+		m.bytesRead.Add(ctx, int64(bytesRead), opt)
+	}
+}
+
+func (m *metrics) decodeFailed(ctx context.Context, bucket string) {
+	m.decodeFailures.Add(ctx, 1, m.with(attribute.String("bucket", bucket)))
+}
+
+func (m *metrics) cacheLookup(ctx context.Context, bucket, result string) {
+	m.cacheLookups.Add(ctx, 1, m.with(attribute.String("bucket", bucket), attribute.String("result", result)))
+}
+
+func (m *metrics) preloadRefreshed(ctx context.Context, bucket, key string, err error) {
+	m.preloads.Add(
+		ctx, 1, m.with(
+			attribute.String("bucket", bucket),
+			attribute.String("key", key),
+			attribute.String("status", status(err)),
+		),
+	)
+}
+
+func (m *metrics) with(attrs ...attribute.KeyValue) metric.MeasurementOption {
+	return metric.WithAttributes(append(attrs, m.attrs...)...)
+}
+
+// status returns the label describing the result of an operation: ok, the s3
+// error code or a generic error.
+func status(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+
+	return "error"
+}
@@ -0,0 +1,96 @@
+package s3_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestBackendFactoryWithClient_metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(prev)
+
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+	expectGetObject(cl, "sample").Times(1).Return(objectOutput(`{"property1": "value1"}`), nil)
+	expectGetObject(cl, "invalid").Times(1).Return(objectOutput(`{`), nil)
+	expectGetObject(cl, "missing").Times(1).Return(nil, &types.NoSuchKey{})
+
+	b := s3.BackendFactoryWithClient(
+		logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			URLPattern: "/{path}",
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":    "bucket1",
+					"cache_ttl": "1h",
+				},
+			},
+		},
+	)
+
+	for _, path := range []string{"/sample", "/sample", "/invalid", "/missing"} {
+		_, _ = p(context.Background(), &proxy.Request{Path: path})
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(context.Background(), &rm); !assert.NoError(t, err) {
+		return
+	}
+
+	got := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+
+	durations := got["s3.backend.duration"].(metricdata.Histogram[float64])
+	counts := map[string]uint64{}
+	for _, dp := range durations.DataPoints {
+		v, _ := dp.Attributes.Value(attribute.Key("status"))
+		counts[v.AsString()] += dp.Count
+
+		pattern, _ := dp.Attributes.Value(attribute.Key("url_pattern"))
+		assert.Equal(t, "/{path}", pattern.AsString())
+	}
+	assert.Equal(t, map[string]uint64{"ok": 2, "NoSuchKey": 1}, counts)
+
+	assert.Equal(t, int64(24), sum(got["s3.backend.bytes_read"]))
+	assert.Equal(t, int64(1), sum(got["s3.backend.decode_failures"]))
+
+	lookups := map[string]int64{}
+	for _, dp := range got["s3.backend.cache_lookups"].(metricdata.Sum[int64]).DataPoints {
+		v, _ := dp.Attributes.Value(attribute.Key("result"))
+		lookups[v.AsString()] += dp.Value
+	}
+	assert.Equal(t, map[string]int64{"hit": 1, "miss": 3}, lookups)
+}
+
+func sum(data metricdata.Aggregation) int64 {
+	var total int64
+	for _, dp := range data.(metricdata.Sum[int64]).DataPoints {
+		total += dp.Value
+	}
+
+	return total
+}
@@ -0,0 +1,198 @@
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FormatJSON decodes the object into the response data, also encoded as
+	// json in the response body.
+	FormatJSON = "json"
+	// FormatYAML decodes the object and encodes it as yaml in the response body.
+	FormatYAML = "yaml"
+	// FormatRaw sends the object content as it is.
+	FormatRaw = "raw"
+
+	formatParam = "format"
+)
+
+var (
+	errInvalidFormats = errors.New(`aws s3: invalid "formats" or "default_format" defined`)
+	errNotAcceptable  = client.HTTPResponseError{Code: http.StatusNotAcceptable, Msg: "aws s3: no acceptable representation of the object"}
+
+	formatMediaTypes = map[string][]string{
+		FormatJSON: {"application/json"},
+		FormatYAML: {"application/yaml", "application/x-yaml", "text/yaml"},
+		FormatRaw:  {"application/octet-stream"},
+	}
+)
+
+// negotiate chooses the format of the response from the format query param
+// or the Accept header of the request, returning an empty format when
+// content negotiation is disabled.
+func (b *backend) negotiate(request *proxy.Request, obj *object) (string, error) {
+	if len(b.opts.Formats) == 0 {
+		return "", nil
+	}
+
+	if format := request.Query.Get(formatParam); format != "" {
+		if !contains(b.opts.Formats, format) {
+			return "", errNotAcceptable
+		}
+
+		return format, nil
+	}
+
+	accept := http.Header(request.Headers).Values("Accept")
+	if len(accept) == 0 {
+		return b.opts.DefaultFormat, nil
+	}
+
+	for _, mediaRange := range acceptedMediaRanges(strings.Join(accept, ",")) {
+		if mediaRange == "*/*" {
+			return b.opts.DefaultFormat, nil
+		}
+
+		for _, format := range b.opts.Formats {
+			mediaTypes := formatMediaTypes[format]
+			if format == FormatRaw && obj.contentType != "" {
+				mediaTypes = append([]string{obj.contentType}, mediaTypes...)
+			}
+
+			for _, mediaType := range mediaTypes {
+				if matchesMediaRange(mediaRange, mediaType) {
+					return format, nil
+				}
+			}
+		}
+	}
+
+	return "", errNotAcceptable
+}
+
+// varyByAccept adds the Accept header to the Vary response header when the
+// response depends on it.
+func (b *backend) varyByAccept(headers map[string][]string) {
+	if len(b.opts.Formats) > 0 {
+		headers["Vary"] = append(headers["Vary"], "Accept")
+	}
+}
+
+// encode writes the response data into the response body in the given
+// format, so it can also be sent by endpoints with the no-op output encoding.
+func encode(response *proxy.Response, format string) error {
+	var (
+		body        []byte
+		err         error
+		contentType string
+	)
+	switch format {
+	case FormatJSON:
+		body, err = json.Marshal(response.Data)
+		contentType = "application/json"
+	case FormatYAML:
+		body, err = yaml.Marshal(response.Data)
+		contentType = "application/yaml"
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	response.Io = bytes.NewReader(body)
+	response.Metadata.Headers["Content-Type"] = []string{contentType}
+
+	return nil
+}
+
+// acceptedMediaRanges parses an Accept header into its media ranges, sorted
+// by decreasing quality and leaving out the ones with a quality of zero.
+func acceptedMediaRanges(accept string) []string {
+	type mediaRange struct {
+		value   string
+		quality float64
+	}
+
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{value: mediaType, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	values := make([]string, len(ranges))
+	for i, r := range ranges {
+		values[i] = r.value
+	}
+
+	return values
+}
+
+func matchesMediaRange(mediaRange, mediaType string) bool {
+	if mediaType, _, err := mime.ParseMediaType(mediaType); err == nil {
+		if prefix := strings.TrimSuffix(mediaRange, "*"); prefix != mediaRange {
+			return strings.HasPrefix(mediaType, prefix)
+		}
+
+		return mediaRange == mediaType
+	}
+
+	return false
+}
+
+func getFormats(cfg map[string]interface{}, opts *Options) error {
+	v, ok := cfg["formats"]
+	if !ok {
+		return nil
+	}
+
+	formats, err := getStrings(v)
+	if err != nil || len(formats) == 0 {
+		return errInvalidFormats
+	}
+
+	for _, format := range formats {
+		if _, ok := formatMediaTypes[format]; !ok {
+			return errInvalidFormats
+		}
+	}
+
+	opts.Formats = formats
+	opts.DefaultFormat = formats[0]
+
+	if v, ok := cfg["default_format"]; ok {
+		format, ok := v.(string)
+		if !ok || !contains(formats, format) {
+			return errInvalidFormats
+		}
+		opts.DefaultFormat = format
+	}
+
+	return nil
+}
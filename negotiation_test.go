@@ -0,0 +1,142 @@
+package s3_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_negotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		request         *proxy.Request
+		wantBody        string
+		wantContentType string
+		wantErr         assert.ErrorAssertionFunc
+	}{
+		{
+			name:            "no preference, should return the default format",
+			request:         &proxy.Request{Path: "/sample"},
+			wantBody:        `{"hello":"world"}`,
+			wantContentType: "application/json",
+			wantErr:         assert.NoError,
+		},
+		{
+			name: "yaml accepted, should return yaml",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"Accept": {"application/yaml"}},
+			},
+			wantBody:        "hello: world\n",
+			wantContentType: "application/yaml",
+			wantErr:         assert.NoError,
+		},
+		{
+			name: "highest quality accepted, should return the preferred format",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"Accept": {"application/json;q=0.5, text/yaml;q=0.9"}},
+			},
+			wantBody:        "hello: world\n",
+			wantContentType: "application/yaml",
+			wantErr:         assert.NoError,
+		},
+		{
+			name: "object content type accepted, should return the raw object",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"Accept": {"text/plain;q=0.8, application/*;q=0.1"}},
+			},
+			wantBody:        `{"hello": "world"}`,
+			wantContentType: "text/plain",
+			wantErr:         assert.NoError,
+		},
+		{
+			name: "format query param, should override the accept header",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"Accept": {"application/json"}},
+				Query:   url.Values{"format": {"raw"}},
+			},
+			wantBody:        `{"hello": "world"}`,
+			wantContentType: "text/plain",
+			wantErr:         assert.NoError,
+		},
+		{
+			name: "wildcard accepted, should return the default format",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"Accept": {"*/*"}},
+			},
+			wantBody:        `{"hello":"world"}`,
+			wantContentType: "application/json",
+			wantErr:         assert.NoError,
+		},
+		{
+			name: "no acceptable format, should return 406",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"Accept": {"text/html, application/json;q=0"}},
+			},
+			wantErr: wantStatusCode(http.StatusNotAcceptable),
+		},
+		{
+			name: "format not allowed, should return 406",
+			request: &proxy.Request{
+				Path:  "/sample",
+				Query: url.Values{"format": {"xml"}},
+			},
+			wantErr: wantStatusCode(http.StatusNotAcceptable),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := mocks.NewMockObjectGetter(ctrl)
+				out := objectOutput(`{"hello": "world"}`)
+				out.ContentType = aws.String("text/plain")
+				expectGetObject(cl, "sample").Return(out, nil)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":  "bucket1",
+								"formats": []interface{}{"json", "yaml", "raw"},
+							},
+						},
+					},
+				)
+
+				got, err := p(context.Background(), tt.request)
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				body, err := io.ReadAll(got.Io)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBody, string(body))
+				assert.Equal(t, []string{tt.wantContentType}, got.Metadata.Headers["Content-Type"])
+				assert.Equal(t, []string{"Accept"}, got.Metadata.Headers["Vary"])
+			},
+		)
+	}
+}
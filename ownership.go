@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aws/smithy-go"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+var (
+	errInvalidBucketOwner = errors.New(`aws s3: invalid "expected_bucket_owner" defined`)
+	accountIDPattern      = regexp.MustCompile(`^\d{12}$`)
+)
+
+// accessDenied replaces the AccessDenied errors returned by s3 with a 403
+// error explaining the likely cause when the requester pays or the expected
+// bucket owner options are set, as s3 does not tell them apart.
+func (b *backend) accessDenied(err error) error {
+	owner := b.targets[0].owner
+	if !b.opts.RequestPayer && owner == "" {
+		return err
+	}
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+		return err
+	}
+
+	causes := []string{}
+	if owner != "" {
+		causes = append(causes, "the bucket is not owned by account "+owner)
+	}
+	if b.opts.RequestPayer {
+		causes = append(causes, "the credentials are not allowed to pay for the request")
+	}
+
+	return client.HTTPResponseError{
+		Code: http.StatusForbidden,
+		Msg:  "aws s3: access denied to bucket " + b.targets[0].bucket + ", " + strings.Join(causes, " or "),
+	}
+}
+
+// getBucketOwner parses the expected bucket owner, which must be a 12 digit
+// aws account id.
+func getBucketOwner(cfg map[string]interface{}) (string, error) {
+	v, ok := cfg["expected_bucket_owner"]
+	if !ok || v == nil {
+		return "", nil
+	}
+
+	owner, ok := v.(string)
+	if !ok || !accountIDPattern.MatchString(owner) {
+		return "", errInvalidBucketOwner
+	}
+
+	return owner, nil
+}
@@ -0,0 +1,159 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_bucketOwner(t *testing.T) {
+	accessDenied := &smithy.GenericAPIError{Code: "AccessDenied", Message: "Access Denied"}
+
+	tests := []struct {
+		name    string
+		cfg     map[string]interface{}
+		setup   func(clients map[string]*mocks.MockObjectGetter)
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "requester pays and bucket owner, should be sent with the request",
+			cfg: map[string]interface{}{
+				"requester_pays":        true,
+				"expected_bucket_owner": "111122223333",
+			},
+			setup: func(clients map[string]*mocks.MockObjectGetter) {
+				clients["bucket1"].EXPECT().GetObject(
+					gomock.Any(), gomock.Eq(
+						&awsS3.GetObjectInput{
+							Bucket:              aws.String("bucket1"),
+							Key:                 aws.String("sample"),
+							ExpectedBucketOwner: aws.String("111122223333"),
+							RequestPayer:        types.RequestPayerRequester,
+						},
+					),
+				).Times(1).Return(objectOutput(`{}`), nil)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "replica with its own bucket owner, should be sent with the replica request",
+			cfg: map[string]interface{}{
+				"expected_bucket_owner": "111122223333",
+				"replicas": []interface{}{
+					map[string]interface{}{"bucket": "bucket2", "expected_bucket_owner": "444455556666"},
+				},
+			},
+			setup: func(clients map[string]*mocks.MockObjectGetter) {
+				clients["bucket1"].EXPECT().GetObject(gomock.Any(), gomock.Any()).
+					Times(1).Return(nil, errors.New("connection reset"))
+				clients["bucket2"].EXPECT().GetObject(
+					gomock.Any(), gomock.Eq(
+						&awsS3.GetObjectInput{
+							Bucket:              aws.String("bucket2"),
+							Key:                 aws.String("sample"),
+							ExpectedBucketOwner: aws.String("444455556666"),
+						},
+					),
+				).Times(1).Return(objectOutput(`{}`), nil)
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "access denied with bucket owner, should explain the error",
+			cfg: map[string]interface{}{
+				"requester_pays":        true,
+				"expected_bucket_owner": "111122223333",
+			},
+			setup: func(clients map[string]*mocks.MockObjectGetter) {
+				clients["bucket1"].EXPECT().GetObject(gomock.Any(), gomock.Any()).
+					Times(1).Return(nil, accessDenied)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(
+					t, client.HTTPResponseError{
+						Code: http.StatusForbidden,
+						Msg: "aws s3: access denied to bucket bucket1, the bucket is not owned by account 111122223333" +
+							" or the credentials are not allowed to pay for the request",
+					}, err, i...,
+				)
+			},
+		},
+		{
+			name: "access denied without bucket owner, should return the s3 error",
+			cfg:  map[string]interface{}{},
+			setup: func(clients map[string]*mocks.MockObjectGetter) {
+				clients["bucket1"].EXPECT().GetObject(gomock.Any(), gomock.Any()).
+					Times(1).Return(nil, accessDenied)
+			},
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.Equal(t, accessDenied, err, i...)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				clients := map[string]*mocks.MockObjectGetter{
+					"bucket1": mocks.NewMockObjectGetter(ctrl),
+					"bucket2": mocks.NewMockObjectGetter(ctrl),
+				}
+				tt.setup(clients)
+
+				cfg := map[string]interface{}{"bucket": "bucket1"}
+				for k, v := range tt.cfg {
+					cfg[k] = v
+				}
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return clients[opts.Bucket]
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: cfg}})
+
+				_, err := p(context.Background(), &proxy.Request{Path: "/sample"})
+				tt.wantErr(t, err)
+			},
+		)
+	}
+}
+
+func TestBackendFactory_invalidBucketOwner(t *testing.T) {
+	for _, owner := range []interface{}{"", "1111-2222-3333", "arn:aws:iam::111122223333:root", 111122223333} {
+		ctrl := gomock.NewController(t)
+		l := mocks.NewMockLogger(ctrl)
+		l.EXPECT().Error(
+			"[BACKEND: /some-endpoint][S3]",
+			errors.New(`aws s3: invalid "expected_bucket_owner" defined`),
+		).Times(1)
+
+		b := s3.BackendFactory(l, noopBackendFactory)
+		b(
+			&config.Backend{
+				URLPattern: "/some-endpoint",
+				ExtraConfig: map[string]interface{}{
+					s3.Namespace: map[string]interface{}{
+						"bucket":                "bucket1",
+						"expected_bucket_owner": owner,
+					},
+				},
+			},
+		)
+	}
+}
@@ -0,0 +1,192 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPreloadTimeout bounds each round of preloading, so a hanging s3
+// can't block the creation of the backend or the background refresh.
+const defaultPreloadTimeout = 5 * time.Second
+
+var errInvalidPreload = errors.New(`aws s3: invalid "preload", "preload_interval" or "preload_timeout" defined`)
+
+// preloadStore keeps in memory the objects of the preloaded keys, which are
+// served from it without ever expiring.
+type preloadStore struct {
+	mu      sync.RWMutex
+	keys    []string
+	objects map[string]*object
+}
+
+func newPreloadStore(keys []string) *preloadStore {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	objects := make(map[string]*object, len(keys))
+	for _, k := range keys {
+		objects[k] = nil
+	}
+
+	return &preloadStore{keys: keys, objects: objects}
+}
+
+// Get returns the object of the preloaded key, if it has been fetched.
+func (s *preloadStore) Get(key string) (*object, bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	obj := s.objects[key]
+	return obj, obj != nil
+}
+
+// Has reports whether the key is a preloaded one, even if its object has not
+// been fetched yet.
+func (s *preloadStore) Has(key string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.objects[key]
+	return ok
+}
+
+// Set stores the object of the key, if it is a preloaded one.
+func (s *preloadStore) Set(key string, obj *object) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.objects[key]; ok {
+		s.objects[key] = obj
+	}
+}
+
+// Delete removes the object of the key, after it has been modified, until it
+// is fetched again.
+func (s *preloadStore) Delete(key string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.objects[key]; ok {
+		s.objects[key] = nil
+	}
+}
+
+// preload fetches the preloaded keys and, when an interval is configured,
+// keeps refreshing them in the background until the context is done. The keys
+// not fetched within the PreloadTimeout at startup are fetched on the request
+// path until then.
+func (b *backend) preload(ctx context.Context) {
+	if b.preloaded == nil {
+		return
+	}
+
+	b.refreshPreloaded(ctx)
+
+	if b.opts.PreloadInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(b.opts.PreloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.refreshPreloaded(ctx)
+			}
+		}
+	}()
+}
+
+// refreshPreloaded fetches every preloaded key within the PreloadTimeout,
+// keeping the previous copy of the ones that fail.
+func (b *backend) refreshPreloaded(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, b.opts.PreloadTimeout)
+	defer cancel()
+
+	bucket := b.targets[0].bucket
+	for _, k := range b.preloaded.keys {
+		obj, err := b.getObjectWithFailover(ctx, k)
+		b.metrics.preloadRefreshed(ctx, bucket, k, err)
+		if err != nil {
+			b.logger.Error(b.logPrefix, "refreshing preloaded object", k, "failed:", err)
+			continue
+		}
+
+		b.preloaded.Set(k, obj)
+	}
+}
+
+// invalidate drops the copies of the object kept in memory, after it has
+// been modified.
+func (b *backend) invalidate(bucket, k string) {
+	b.cache.Delete(bucket, k)
+
+	if len(b.targets) > 0 && b.targets[0].bucket == bucket {
+		b.preloaded.Delete(k)
+	}
+}
+
+// getPreload parses the preloaded keys, which can't depend on the request
+// and so are only supported by backends without tenants.
+func getPreload(cfg map[string]interface{}, opts *Options) error {
+	interval, err := getDuration(cfg, "preload_interval")
+	if err != nil {
+		return errInvalidPreload
+	}
+
+	v, ok := cfg["preload"]
+	if !ok {
+		if _, ok := cfg["preload_timeout"]; ok || interval > 0 {
+			return errInvalidPreload
+		}
+		return nil
+	}
+
+	keys, err := getStrings(v)
+	if err != nil || len(keys) == 0 || len(opts.Tenants) > 0 {
+		return errInvalidPreload
+	}
+
+	for _, k := range keys {
+		if strings.HasPrefix(k, "/") || placeholderPattern.MatchString(k) {
+			return errInvalidPreload
+		}
+	}
+
+	timeout := defaultPreloadTimeout
+	if _, ok := cfg["preload_timeout"]; ok {
+		if timeout, err = getDuration(cfg, "preload_timeout"); err != nil || timeout <= 0 {
+			return errInvalidPreload
+		}
+	}
+
+	opts.Preload = keys
+	opts.PreloadInterval = interval
+	opts.PreloadTimeout = timeout
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestBackendFactoryWithClientContext_preload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+	expectGetObject(cl, "flags.json").Times(1).Return(objectOutput(`{"beta": true}`), nil)
+	expectGetObject(cl, "other.json").Times(1).Return(objectOutput(`{"other": true}`), nil)
+	expectGetObject(cl, "other.json").Times(1).Return(objectOutput(`{"other": true}`), nil)
+
+	b := s3.BackendFactoryWithClientContext(
+		context.Background(), logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":  "bucket1",
+					"preload": []interface{}{"flags.json"},
+				},
+			},
+		},
+	)
+
+	for _, path := range []string{"/flags.json", "/flags.json", "/other.json", "/other.json"} {
+		got, err := p(context.Background(), &proxy.Request{Path: path})
+		assert.NoError(t, err)
+		assert.NotNil(t, got)
+	}
+
+	got, _ := p(context.Background(), &proxy.Request{Path: "/flags.json"})
+	assert.Equal(t, map[string]interface{}{"beta": true}, got.Data)
+}
+
+func TestBackendFactoryWithClientContext_preloadFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+	logger := mocks.NewMockLogger(ctrl)
+	gomock.InOrder(
+		expectGetObject(cl, "flags.json").Times(1).Return(nil, errors.New("connection reset")),
+		expectGetObject(cl, "flags.json").Times(1).Return(objectOutput(`{"beta": true}`), nil),
+	)
+	logger.EXPECT().Error(
+		"[BACKEND: /flags][S3]", "refreshing preloaded object", "flags.json", "failed:", gomock.Any(),
+	).Times(1)
+
+	b := s3.BackendFactoryWithClientContext(
+		context.Background(), logger, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			URLPattern: "/flags",
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":  "bucket1",
+					"preload": []interface{}{"flags.json"},
+				},
+			},
+		},
+	)
+
+	// the object is fetched on the request path and kept in memory from then.
+	for i := 0; i < 2; i++ {
+		got, err := p(context.Background(), &proxy.Request{Path: "/flags.json"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[string]interface{}{"beta": true}, got.Data)
+		}
+	}
+}
+
+func TestBackendFactoryWithClientContext_preloadTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+	gomock.InOrder(
+		expectGetObject(cl, "flags.json").Times(1).DoAndReturn(
+			func(ctx context.Context, _ *awsS3.GetObjectInput, _ ...func(*awsS3.Options)) (*awsS3.GetObjectOutput, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		),
+		expectGetObject(cl, "flags.json").Times(1).Return(objectOutput(`{"beta": true}`), nil),
+	)
+
+	b := s3.BackendFactoryWithClientContext(
+		context.Background(), logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+
+	start := time.Now()
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":          "bucket1",
+					"preload":         []interface{}{"flags.json"},
+					"preload_timeout": "20ms",
+				},
+			},
+		},
+	)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// the key not preloaded in time is fetched on the request path.
+	got, err := p(context.Background(), &proxy.Request{Path: "/flags.json"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]interface{}{"beta": true}, got.Data)
+	}
+}
+
+func TestBackendFactoryWithClientContext_preloadRefresh(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(prev)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	cl := mocks.NewMockObjectGetter(ctrl)
+	gomock.InOrder(
+		expectGetObject(cl, "flags.json").Times(1).Return(objectOutput(`{"version": 1}`), nil),
+		expectGetObject(cl, "flags.json").Times(1).Return(nil, errors.New("connection reset")),
+		expectGetObject(cl, "flags.json").MinTimes(1).DoAndReturn(
+			func(_ context.Context, _ *awsS3.GetObjectInput, _ ...func(*awsS3.Options)) (*awsS3.GetObjectOutput, error) {
+				return objectOutput(`{"version": 2}`), nil
+			},
+		),
+	)
+
+	b := s3.BackendFactoryWithClientContext(
+		ctx, logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":           "bucket1",
+					"preload":          []interface{}{"flags.json"},
+					"preload_interval": "10ms",
+				},
+			},
+		},
+	)
+
+	got, err := p(context.Background(), &proxy.Request{Path: "/flags.json"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]interface{}{"version": float64(1)}, got.Data)
+	}
+
+	assert.Eventually(
+		t, func() bool {
+			got, err := p(context.Background(), &proxy.Request{Path: "/flags.json"})
+			return err == nil && got.Data["version"] == float64(2)
+		}, time.Second, 5*time.Millisecond,
+	)
+	cancel()
+
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(context.Background(), &rm); !assert.NoError(t, err) {
+		return
+	}
+
+	refreshes := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "s3.backend.preload_refreshes" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				v, _ := dp.Attributes.Value(attribute.Key("status"))
+				refreshes[v.AsString()] += dp.Value
+			}
+		}
+	}
+	assert.Equal(t, int64(1), refreshes["error"])
+	assert.GreaterOrEqual(t, refreshes["ok"], int64(2))
+}
@@ -0,0 +1,269 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+const (
+	defaultPostExpiration = 15 * time.Minute
+	maxPostExpiration     = 7 * 24 * time.Hour
+	signingAlgorithm      = "AWS4-HMAC-SHA256"
+	contentTypeParam      = "content_type"
+)
+
+var (
+	errInvalidPostPolicy      = errors.New(`aws s3: invalid "post_policy" defined`)
+	errMissingKeyPrefix       = client.HTTPResponseError{Code: http.StatusForbidden, Msg: "aws s3: the key prefix can not be resolved for the request"}
+	errUnsupportedContentType = client.HTTPResponseError{Code: http.StatusUnsupportedMediaType, Msg: "aws s3: content type not allowed"}
+)
+
+// PostPolicyOptions are the conditions of the presigned POST forms.
+type PostPolicyOptions struct {
+	// KeyPrefix is the template of the prefix the uploaded keys must start
+	// with, usually bound to the authenticated user, i.e. uploads/{header.X-User}/.
+	KeyPrefix        string
+	MinContentLength int64
+	MaxContentLength int64
+	// ContentTypes are the content types the client can choose from with the
+	// content_type query param. Any is allowed when empty.
+	ContentTypes []string
+	Expiration   time.Duration
+}
+
+// presignPost returns the url and the form fields a client posts, along with
+// the file, to upload an object straight to the bucket. The form is signed
+// with a policy enforcing the configured conditions.
+func (b *backend) presignPost(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	t := b.targets[0]
+	policy := b.opts.PostPolicy
+
+	prefix, ok := expandPrefix(policy.KeyPrefix, request)
+	if !ok {
+		return nil, errMissingKeyPrefix
+	}
+
+	if err := b.checkAccess(prefix); err != nil {
+		return nil, err
+	}
+
+	contentType := request.Query.Get(contentTypeParam)
+	if len(policy.ContentTypes) > 0 && !contains(policy.ContentTypes, contentType) {
+		return nil, errUnsupportedContentType
+	}
+
+	creds, err := t.config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	region := t.config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	now := time.Now().UTC()
+	date := now.Format("20060102")
+	expiresAt := now.Add(policy.Expiration)
+
+	fields := map[string]string{
+		"key":              prefix + "${filename}",
+		"x-amz-algorithm":  signingAlgorithm,
+		"x-amz-credential": strings.Join([]string{creds.AccessKeyID, date, region, "s3", "aws4_request"}, "/"),
+		"x-amz-date":       now.Format("20060102T150405Z"),
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+	if contentType != "" {
+		fields["Content-Type"] = contentType
+	}
+
+	conditions := []interface{}{
+		map[string]string{"bucket": t.bucket},
+		[]interface{}{"starts-with", "$key", prefix},
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if name != "key" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conditions = append(conditions, map[string]string{name: fields[name]})
+	}
+	if policy.MaxContentLength > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", policy.MinContentLength, policy.MaxContentLength})
+	}
+
+	doc, err := json.Marshal(
+		map[string]interface{}{
+			"expiration": expiresAt.Format("2006-01-02T15:04:05.000Z"),
+			"conditions": conditions,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	fields["policy"] = base64.StdEncoding.EncodeToString(doc)
+	fields["x-amz-signature"] = hex.EncodeToString(
+		hmacSHA256(signingKey(creds.SecretAccessKey, date, region), fields["policy"]),
+	)
+
+	formFields := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		formFields[name] = value
+	}
+
+	url, err := bucketURL(t, region)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.format(
+		ctx, proxy.Response{
+			Data: map[string]interface{}{
+				"url":        url,
+				"fields":     formFields,
+				"expires_at": expiresAt.Format(time.RFC3339),
+			},
+			IsComplete: true,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: http.StatusOK,
+			},
+		},
+	), nil
+}
+
+// expandPrefix expands the key prefix template, failing when any placeholder
+// resolves to an empty value or to one with a slash, which would let the
+// client choose a prefix other than its own.
+func expandPrefix(tmpl string, request *proxy.Request) (string, bool) {
+	for _, m := range placeholderPattern.FindAllString(tmpl, -1) {
+		v := expandKey(m, request)
+		if v == "" || strings.Contains(v, "/") {
+			return "", false
+		}
+	}
+
+	return expandKey(tmpl, request), true
+}
+
+// bucketURL returns the url the forms are posted to: the path of the bucket
+// on the configured endpoint or its virtual host on aws otherwise.
+func bucketURL(t target, region string) (string, error) {
+	if t.config.EndpointResolverWithOptions == nil {
+		return "https://" + t.bucket + ".s3." + region + ".amazonaws.com", nil
+	}
+
+	endpoint, err := t.config.EndpointResolverWithOptions.ResolveEndpoint("s3", region)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(endpoint.URL, "/") + "/" + t.bucket, nil
+}
+
+// signingKey derives the signature version 4 key of the day and region.
+func signingKey(secret, date, region string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, "s3")
+
+	return hmacSHA256(k, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+func contains(vs []string, v string) bool {
+	for _, s := range vs {
+		if s == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getPostPolicy parses the conditions of the presign_post operation, which
+// requires credentials to sign the forms.
+func getPostPolicy(cfg map[string]interface{}, opts *Options) (*PostPolicyOptions, error) {
+	if opts.Operation != OperationPresignPost {
+		return nil, nil
+	}
+
+	pc, ok := cfg["post_policy"].(map[string]interface{})
+	if !ok {
+		return nil, errInvalidPostPolicy
+	}
+
+	policy := &PostPolicyOptions{Expiration: defaultPostExpiration}
+
+	policy.KeyPrefix, ok = pc["key_prefix"].(string)
+	if !ok || policy.KeyPrefix == "" || strings.HasPrefix(policy.KeyPrefix, "/") {
+		return nil, errInvalidPostPolicy
+	}
+
+	if _, ok := pc["min_content_length"]; ok {
+		v, ok := getInt(pc, "min_content_length")
+		if !ok || v < 0 {
+			return nil, errInvalidPostPolicy
+		}
+		policy.MinContentLength = int64(v)
+	}
+
+	if _, ok := pc["max_content_length"]; ok {
+		v, ok := getInt(pc, "max_content_length")
+		if !ok || int64(v) < policy.MinContentLength || v <= 0 {
+			return nil, errInvalidPostPolicy
+		}
+		policy.MaxContentLength = int64(v)
+	}
+
+	if v, ok := pc["content_types"]; ok {
+		contentTypes, err := getStrings(v)
+		if err != nil {
+			return nil, errInvalidPostPolicy
+		}
+		policy.ContentTypes = contentTypes
+	}
+
+	expiration, err := getDuration(pc, "expiration")
+	if err != nil || expiration < 0 || expiration > maxPostExpiration {
+		return nil, errInvalidPostPolicy
+	}
+	if expiration > 0 {
+		policy.Expiration = expiration
+	}
+
+	if opts.AWSConfig.Credentials == nil && len(opts.Tenants) == 0 {
+		return nil, errInvalidPostPolicy
+	}
+	for _, t := range opts.Tenants {
+		if t.AWSConfig.Credentials == nil {
+			return nil, errInvalidPostPolicy
+		}
+	}
+
+	return policy, nil
+}
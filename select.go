@@ -0,0 +1,264 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+const (
+	// SelectJSONLines queries objects with one json document per line.
+	SelectJSONLines = "json_lines"
+	// SelectJSON queries objects holding a single json document.
+	SelectJSON = "json"
+	// SelectCSV queries csv objects.
+	SelectCSV = "csv"
+
+	// defaultSelectMaxBytes bounds the memory used by the selected records
+	// when no max_bytes is defined.
+	defaultSelectMaxBytes = 10 << 20
+)
+
+var (
+	errInvalidSelect     = errors.New(`aws s3: invalid "select" defined`)
+	errSelectUnsupported = errors.New("aws s3: the s3 client does not support select")
+	errNoSelectStream    = errors.New("aws s3: select returned no event stream")
+	errSelectTooLarge    = client.HTTPResponseError{
+		Code: http.StatusBadGateway,
+		Msg:  "aws s3: the selected records exceed the maximum size or number of records",
+	}
+)
+
+// SelectOptions configures the S3 Select query issued instead of fetching the
+// whole object.
+type SelectOptions struct {
+	// Expression is the SQL expression to run. {name} placeholders are bound
+	// to the query string or request param with that name as quoted literals.
+	Expression  string
+	InputFormat string
+	// CSVHeader uses the first line of csv objects as the column names.
+	CSVHeader   bool
+	Compression types.CompressionType
+	// MaxBytes and MaxRecords bound the selected records kept in memory to
+	// build the response, failing the request when they are exceeded. No
+	// limit is set on the records when MaxRecords is zero.
+	MaxBytes   int
+	MaxRecords int
+}
+
+// selectObject runs the select expression against the object matching the
+// request and returns the selected records as a collection.
+func (b *backend) selectObject(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	t := b.targets[0]
+	selector, ok := t.client.(ObjectSelector)
+	if !ok {
+		return nil, errSelectUnsupported
+	}
+
+	k := candidateKeys(b.opts, request)[0].key
+	if err := b.checkAccess(k); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	start := time.Now()
+	metadata, err := b.selectRecords(ctx, selector, t, k, request, buf)
+	b.metrics.observe(ctx, "SelectObjectContent", t.bucket, start, buf.Len(), err)
+	b.logOperation("SelectObjectContent", t.bucket, k, start, metadata, err)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := []interface{}{}
+	dec := json.NewDecoder(buf)
+	for {
+		var record interface{}
+		if err := dec.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			b.metrics.decodeFailed(ctx, t.bucket)
+			return nil, err
+		}
+
+		if max := b.opts.Select.MaxRecords; max > 0 && len(collection) == max {
+			return nil, errSelectTooLarge
+		}
+		collection = append(collection, record)
+	}
+
+	response := b.format(
+		ctx, proxy.Response{
+			Data:       map[string]interface{}{"collection": collection},
+			IsComplete: true,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: http.StatusOK,
+			},
+		},
+	)
+
+	return response, nil
+}
+
+// selectRecords runs the select expression and writes the payload of the
+// returned records into buf, stopping the query as soon as they exceed the
+// maximum size. It returns the metadata of the response, once received.
+func (b *backend) selectRecords(
+	ctx context.Context,
+	selector ObjectSelector,
+	t target,
+	k string,
+	request *proxy.Request,
+	buf *bytes.Buffer,
+) (middleware.Metadata, error) {
+	in := &s3.SelectObjectContentInput{
+		Bucket:              &t.bucket,
+		Key:                 &k,
+		Expression:          aws.String(bindExpression(b.opts.Select.Expression, request)),
+		ExpressionType:      types.ExpressionTypeSql,
+		InputSerialization:  b.opts.Select.inputSerialization(),
+		OutputSerialization: &types.OutputSerialization{JSON: &types.JSONOutput{RecordDelimiter: aws.String("\n")}},
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+	}
+
+	out, err := selector.SelectObjectContent(ctx, in)
+	if err != nil {
+		return middleware.Metadata{}, err
+	}
+
+	stream := out.GetStream()
+	if stream == nil {
+		return out.ResultMetadata, errNoSelectStream
+	}
+	defer stream.Close()
+
+	for event := range stream.Events() {
+		if records, ok := event.(*types.SelectObjectContentEventStreamMemberRecords); ok {
+			if buf.Len()+len(records.Value.Payload) > b.opts.Select.MaxBytes {
+				return out.ResultMetadata, errSelectTooLarge
+			}
+			buf.Write(records.Value.Payload)
+		}
+	}
+
+	return out.ResultMetadata, stream.Err()
+}
+
+func (o *SelectOptions) inputSerialization() *types.InputSerialization {
+	in := &types.InputSerialization{CompressionType: o.Compression}
+
+	switch o.InputFormat {
+	case SelectCSV:
+		header := types.FileHeaderInfoNone
+		if o.CSVHeader {
+			header = types.FileHeaderInfoUse
+		}
+		in.CSV = &types.CSVInput{FileHeaderInfo: header}
+	case SelectJSON:
+		in.JSON = &types.JSONInput{Type: types.JSONTypeDocument}
+	default:
+		in.JSON = &types.JSONInput{Type: types.JSONTypeLines}
+	}
+
+	return in
+}
+
+// bindExpression replaces the {name} placeholders of the expression with the
+// matching query string or request param, quoted as a SQL string literal so
+// the values can not alter the expression. Missing values are bound as NULL.
+func bindExpression(expression string, request *proxy.Request) string {
+	return placeholderPattern.ReplaceAllStringFunc(
+		expression, func(m string) string {
+			name := m[1 : len(m)-1]
+
+			v, ok := request.Query[name]
+			if ok && len(v) > 0 {
+				return quoteLiteral(v[0])
+			}
+
+			if p, ok := lookupParam(request, name); ok {
+				return quoteLiteral(p)
+			}
+
+			return "NULL"
+		},
+	)
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func getSelectOptions(cfg map[string]interface{}) (*SelectOptions, error) {
+	v, ok := cfg["select"]
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	sc, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errInvalidSelect
+	}
+
+	expression, ok := sc["expression"].(string)
+	if !ok || expression == "" {
+		return nil, errInvalidSelect
+	}
+
+	opts := &SelectOptions{
+		Expression:  expression,
+		InputFormat: SelectJSONLines,
+		Compression: types.CompressionTypeNone,
+		MaxBytes:    defaultSelectMaxBytes,
+	}
+
+	if _, ok := sc["max_bytes"]; ok {
+		if opts.MaxBytes, ok = getInt(sc, "max_bytes"); !ok || opts.MaxBytes < 1 {
+			return nil, errInvalidSelect
+		}
+	}
+
+	if _, ok := sc["max_records"]; ok {
+		if opts.MaxRecords, ok = getInt(sc, "max_records"); !ok || opts.MaxRecords < 1 {
+			return nil, errInvalidSelect
+		}
+	}
+
+	if format, ok := sc["input_format"].(string); ok {
+		switch format {
+		case SelectJSONLines, SelectJSON, SelectCSV:
+			opts.InputFormat = format
+		default:
+			return nil, errInvalidSelect
+		}
+	}
+
+	if header, ok := sc["csv_header"].(bool); ok {
+		opts.CSVHeader = header
+	}
+
+	if compression, ok := sc["compression"].(string); ok {
+		switch c := types.CompressionType(strings.ToUpper(compression)); c {
+		case types.CompressionTypeNone, types.CompressionTypeGzip, types.CompressionTypeBzip2:
+			opts.Compression = c
+		default:
+			return nil, errInvalidSelect
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,185 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactory_select(t *testing.T) {
+	var gotPath, gotBody string
+	srv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				gotPath = r.URL.Path
+				gotBody = string(body)
+
+				w.WriteHeader(http.StatusOK)
+				// a record split across two events to check the payloads are reassembled.
+				writeEvent(t, w, "Records", []byte(`{"id":"1","country":"ES"}`+"\n"+`{"id":"2",`))
+				writeEvent(t, w, "Records", []byte(`"country":"ES"}`+"\n"))
+				writeEvent(t, w, "Stats", []byte(`<Stats></Stats>`))
+				writeEvent(t, w, "End", nil)
+			},
+		),
+	)
+	defer srv.Close()
+
+	b := s3.BackendFactory(logging.NoOp, noopBackendFactory)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":         "bucket1",
+					"region":         "eu-west-1",
+					"endpoint":       srv.URL,
+					"path_extension": "jsonl",
+					"select": map[string]interface{}{
+						"expression": "SELECT * FROM S3Object s WHERE s.country = {country} AND s.city = {city}",
+					},
+				},
+			},
+		},
+	)
+
+	got, err := p(
+		context.Background(), &proxy.Request{
+			Path:  "/customers",
+			Query: map[string][]string{"country": {"ES' OR '1'='1"}},
+		},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "/bucket1/customers.jsonl", gotPath)
+	assert.Contains(t, gotBody, "s.country = &#39;ES&#39;&#39; OR &#39;&#39;1&#39;&#39;=&#39;&#39;1&#39; AND s.city = NULL")
+	assert.Contains(t, gotBody, "<Type>LINES</Type>")
+	assert.Equal(
+		t, map[string]interface{}{
+			"collection": []interface{}{
+				map[string]interface{}{"id": "1", "country": "ES"},
+				map[string]interface{}{"id": "2", "country": "ES"},
+			},
+		}, got.Data,
+	)
+}
+
+func TestBackendFactory_selectLimits(t *testing.T) {
+	srv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				writeEvent(t, w, "Records", []byte(`{"id":"1","country":"ES"}`+"\n"))
+				writeEvent(t, w, "Records", []byte(`{"id":"2","country":"ES"}`+"\n"))
+				writeEvent(t, w, "End", nil)
+			},
+		),
+	)
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		limits  map[string]interface{}
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "records within the limits, should return them",
+			limits:  map[string]interface{}{"max_bytes": 52, "max_records": 2},
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "records over max_bytes, should fail",
+			limits:  map[string]interface{}{"max_bytes": 51},
+			wantErr: wantStatusCode(http.StatusBadGateway),
+		},
+		{
+			name:    "records over max_records, should fail",
+			limits:  map[string]interface{}{"max_records": 1},
+			wantErr: wantStatusCode(http.StatusBadGateway),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				selectCfg := map[string]interface{}{"expression": "SELECT * FROM S3Object s"}
+				for k, v := range tt.limits {
+					selectCfg[k] = v
+				}
+
+				b := s3.BackendFactory(logging.NoOp, noopBackendFactory)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":   "bucket1",
+								"region":   "eu-west-1",
+								"endpoint": srv.URL,
+								"select":   selectCfg,
+							},
+						},
+					},
+				)
+
+				got, err := p(context.Background(), &proxy.Request{Path: "/customers"})
+				if !tt.wantErr(t, err) || err != nil {
+					return
+				}
+
+				assert.Len(t, got.Data["collection"], 2)
+			},
+		)
+	}
+}
+
+func TestBackendFactory_invalidSelect(t *testing.T) {
+	called := false
+	b := s3.BackendFactory(
+		logging.NoOp, func(remote *config.Backend) proxy.Proxy {
+			called = true
+			return proxy.NoopProxy
+		},
+	)
+	b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket": "bucket1",
+					"select": map[string]interface{}{
+						"expression":   "SELECT * FROM S3Object",
+						"input_format": "xml",
+					},
+				},
+			},
+		},
+	)
+
+	assert.True(t, called)
+}
+
+func writeEvent(t *testing.T, w io.Writer, eventType string, payload []byte) {
+	msg := eventstream.Message{Payload: payload}
+	msg.Headers.Set(":message-type", eventstream.StringValue("event"))
+	msg.Headers.Set(":event-type", eventstream.StringValue(eventType))
+	msg.Headers.Set(":content-type", eventstream.StringValue("application/octet-stream"))
+
+	buf := &bytes.Buffer{}
+	if err := eventstream.NewEncoder().Encode(buf, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+}
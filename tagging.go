@@ -0,0 +1,240 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+var (
+	errInvalidTags        = errors.New(`aws s3: invalid "tags_field" or "tags_header" defined`)
+	errTaggingUnsupported = errors.New("aws s3: the s3 client does not support tagging")
+	errInvalidTagsBody    = client.HTTPResponseError{Code: http.StatusBadRequest, Msg: "aws s3: the request body must be an object of string tags"}
+)
+
+// tags reads or writes the tags of the object matching the request. Written
+// tags replace the existing ones, or are merged into them when TagsMerge is set.
+func (b *backend) tags(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	t := b.targets[0]
+	tagger, ok := t.client.(ObjectTagger)
+	if !ok {
+		return nil, errTaggingUnsupported
+	}
+
+	k := candidateKeys(b.opts, request)[0].key
+	if err := b.checkAccess(k); err != nil {
+		return nil, err
+	}
+
+	var (
+		tags      map[string]string
+		versionID string
+		err       error
+	)
+	if b.opts.Operation == OperationGetTags {
+		tags, versionID, err = b.getTags(ctx, tagger, t, k)
+	} else {
+		tags, versionID, err = b.putTags(ctx, tagger, t, k, request)
+	}
+	if err != nil {
+		return nil, tagsError(err)
+	}
+
+	return b.format(
+		ctx, proxy.Response{
+			Data: map[string]interface{}{
+				"key":        k,
+				"tags":       tagsData(tags),
+				"version_id": versionID,
+			},
+			IsComplete: true,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: http.StatusOK,
+			},
+		},
+	), nil
+}
+
+func (b *backend) getTags(ctx context.Context, tagger ObjectTagger, t target, k string) (map[string]string, string, error) {
+	in := &s3.GetObjectTaggingInput{
+		Bucket: &t.bucket,
+		Key:    &k,
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+	}
+	if b.opts.RequestPayer {
+		in.RequestPayer = types.RequestPayerRequester
+	}
+
+	start := time.Now()
+	out, err := tagger.GetObjectTagging(ctx, in)
+	metadata := middleware.Metadata{}
+	if out != nil {
+		metadata = out.ResultMetadata
+	}
+	b.metrics.observe(ctx, "GetObjectTagging", t.bucket, start, 0, err)
+	b.logOperation("GetObjectTagging", t.bucket, k, start, metadata, err)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tags := make(map[string]string, len(out.TagSet))
+	for _, tag := range out.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return tags, aws.ToString(out.VersionId), nil
+}
+
+func (b *backend) putTags(
+	ctx context.Context,
+	tagger ObjectTagger,
+	t target,
+	k string,
+	request *proxy.Request,
+) (map[string]string, string, error) {
+	if request.Body == nil {
+		return nil, "", errInvalidTagsBody
+	}
+	defer request.Body.Close()
+
+	tags := map[string]string{}
+	if err := json.NewDecoder(request.Body).Decode(&tags); err != nil {
+		return nil, "", errInvalidTagsBody
+	}
+
+	if b.opts.TagsMerge {
+		current, _, err := b.getTags(ctx, tagger, t, k)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for name, value := range tags {
+			current[name] = value
+		}
+		tags = current
+	}
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tagSet := make([]types.Tag, 0, len(tags))
+	for _, name := range names {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(name), Value: aws.String(tags[name])})
+	}
+
+	in := &s3.PutObjectTaggingInput{
+		Bucket:  &t.bucket,
+		Key:     &k,
+		Tagging: &types.Tagging{TagSet: tagSet},
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+	}
+	if b.opts.RequestPayer {
+		in.RequestPayer = types.RequestPayerRequester
+	}
+
+	start := time.Now()
+	out, err := tagger.PutObjectTagging(ctx, in)
+	metadata := middleware.Metadata{}
+	if out != nil {
+		metadata = out.ResultMetadata
+	}
+	b.metrics.observe(ctx, "PutObjectTagging", t.bucket, start, 0, err)
+	b.logOperation("PutObjectTagging", t.bucket, k, start, metadata, err)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return tags, aws.ToString(out.VersionId), nil
+}
+
+// includeTags adds the tags of the object to the response, in the data field
+// and the header configured for them, if any.
+func (b *backend) includeTags(response *proxy.Response, obj *object) {
+	if b.opts.TagsField != "" && response.Data != nil {
+		response.Data[b.opts.TagsField] = tagsData(obj.tags)
+	}
+
+	if b.opts.TagsHeader != "" && len(obj.tags) > 0 {
+		response.Metadata.Headers[b.opts.TagsHeader] = []string{encodeTags(obj.tags)}
+	}
+}
+
+// includesTags reports whether the responses of the objects include their
+// tags.
+func (o *Options) includesTags() bool {
+	return o.TagsField != "" || o.TagsHeader != ""
+}
+
+func tagsData(tags map[string]string) map[string]interface{} {
+	data := make(map[string]interface{}, len(tags))
+	for name, value := range tags {
+		data[name] = value
+	}
+
+	return data
+}
+
+// tagsError turns the errors caused by the request, a missing object or
+// invalid tags, into 404 and 400 errors.
+func tagsError(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	switch apiErr.ErrorCode() {
+	case "NoSuchKey":
+		return client.HTTPResponseError{Code: http.StatusNotFound, Msg: "aws s3: the object does not exist"}
+	case "InvalidTag", "BadRequest":
+		return client.HTTPResponseError{Code: http.StatusBadRequest, Msg: "aws s3: invalid tags: " + apiErr.ErrorMessage()}
+	}
+
+	return err
+}
+
+func getTagsOptions(cfg map[string]interface{}, opts *Options) error {
+	if merge, ok := cfg["tags_merge"].(bool); ok {
+		opts.TagsMerge = merge
+	}
+
+	for name, dst := range map[string]*string{
+		"tags_field":  &opts.TagsField,
+		"tags_header": &opts.TagsHeader,
+	} {
+		v, ok := cfg[name]
+		if !ok {
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return errInvalidTags
+		}
+		*dst = s
+	}
+
+	if opts.TagsHeader != "" {
+		opts.TagsHeader = http.CanonicalHeaderKey(opts.TagsHeader)
+	}
+
+	return nil
+}
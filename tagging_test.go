@@ -0,0 +1,291 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+// taggerClient is an s3 client supporting tagging.
+type taggerClient struct {
+	*mocks.MockObjectGetter
+	*mocks.MockObjectTagger
+}
+
+func TestBackendFactoryWithClient_tags(t *testing.T) {
+	taggingInput := &awsS3.GetObjectTaggingInput{
+		Bucket: aws.String("bucket1"),
+		Key:    aws.String("sample"),
+	}
+	taggingOutput := &awsS3.GetObjectTaggingOutput{
+		TagSet: []types.Tag{
+			{Key: aws.String("classification"), Value: aws.String("internal")},
+			{Key: aws.String("retention"), Value: aws.String("30d")},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     map[string]interface{}
+		body    string
+		setup   func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger)
+		want    *proxy.Response
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "get tags, should return the tags of the object",
+			cfg:  map[string]interface{}{"operation": "get_tags"},
+			setup: func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger) {
+				tagger.EXPECT().GetObjectTagging(gomock.Any(), gomock.Eq(taggingInput)).Times(1).Return(taggingOutput, nil)
+			},
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"key":        "sample",
+					"tags":       map[string]interface{}{"classification": "internal", "retention": "30d"},
+					"version_id": "",
+				},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusOK},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "get tags of a missing object, should return 404",
+			cfg:  map[string]interface{}{"operation": "get_tags"},
+			setup: func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger) {
+				tagger.EXPECT().GetObjectTagging(gomock.Any(), gomock.Any()).Times(1).
+					Return(nil, &smithy.GenericAPIError{Code: "NoSuchKey"})
+			},
+			wantErr: wantStatusCode(http.StatusNotFound),
+		},
+		{
+			name: "put tags, should replace the tags of the object",
+			cfg:  map[string]interface{}{"operation": "put_tags"},
+			body: `{"retention": "90d", "classification": "public"}`,
+			setup: func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger) {
+				tagger.EXPECT().PutObjectTagging(
+					gomock.Any(), gomock.Eq(
+						&awsS3.PutObjectTaggingInput{
+							Bucket: aws.String("bucket1"),
+							Key:    aws.String("sample"),
+							Tagging: &types.Tagging{
+								TagSet: []types.Tag{
+									{Key: aws.String("classification"), Value: aws.String("public")},
+									{Key: aws.String("retention"), Value: aws.String("90d")},
+								},
+							},
+						},
+					),
+				).Times(1).Return(&awsS3.PutObjectTaggingOutput{VersionId: aws.String("v1")}, nil)
+			},
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"key":        "sample",
+					"tags":       map[string]interface{}{"classification": "public", "retention": "90d"},
+					"version_id": "v1",
+				},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusOK},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "put tags with merge, should merge them into the existing ones",
+			cfg:  map[string]interface{}{"operation": "put_tags", "tags_merge": true},
+			body: `{"retention": "90d", "owner": "team-a"}`,
+			setup: func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger) {
+				tagger.EXPECT().GetObjectTagging(gomock.Any(), gomock.Eq(taggingInput)).Times(1).Return(taggingOutput, nil)
+				tagger.EXPECT().PutObjectTagging(
+					gomock.Any(), gomock.Eq(
+						&awsS3.PutObjectTaggingInput{
+							Bucket: aws.String("bucket1"),
+							Key:    aws.String("sample"),
+							Tagging: &types.Tagging{
+								TagSet: []types.Tag{
+									{Key: aws.String("classification"), Value: aws.String("internal")},
+									{Key: aws.String("owner"), Value: aws.String("team-a")},
+									{Key: aws.String("retention"), Value: aws.String("90d")},
+								},
+							},
+						},
+					),
+				).Times(1).Return(&awsS3.PutObjectTaggingOutput{}, nil)
+			},
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"key": "sample",
+					"tags": map[string]interface{}{
+						"classification": "internal",
+						"owner":          "team-a",
+						"retention":      "90d",
+					},
+					"version_id": "",
+				},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusOK},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "put tags with an invalid body, should return 400",
+			cfg:  map[string]interface{}{"operation": "put_tags"},
+			body: `{"retention": 90}`,
+			setup: func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger) {
+			},
+			wantErr: wantStatusCode(http.StatusBadRequest),
+		},
+		{
+			name: "get object with tags, should include them in a field and a header",
+			cfg:  map[string]interface{}{"tags_field": "_tags", "tags_header": "x-object-tags"},
+			setup: func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger) {
+				out := objectOutput(`{"property1": "value1"}`)
+				out.TagCount = 2
+				expectGetObject(getter, "sample").Times(1).Return(out, nil)
+				tagger.EXPECT().GetObjectTagging(gomock.Any(), gomock.Eq(taggingInput)).Times(1).Return(taggingOutput, nil)
+			},
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"property1": "value1",
+					"_tags":     map[string]interface{}{"classification": "internal", "retention": "30d"},
+				},
+				IsComplete: true,
+				Metadata: proxy.Metadata{
+					Headers:    map[string][]string{"X-Object-Tags": {"classification=internal&retention=30d"}},
+					StatusCode: http.StatusOK,
+				},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "get object without tags, should not read them",
+			cfg:  map[string]interface{}{"tags_field": "_tags"},
+			setup: func(getter *mocks.MockObjectGetter, tagger *mocks.MockObjectTagger) {
+				expectGetObject(getter, "sample").Times(1).Return(objectOutput(`{"property1": "value1"}`), nil)
+			},
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"property1": "value1",
+					"_tags":     map[string]interface{}{},
+				},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusOK},
+			},
+			wantErr: assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := taggerClient{mocks.NewMockObjectGetter(ctrl), mocks.NewMockObjectTagger(ctrl)}
+				tt.setup(cl.MockObjectGetter, cl.MockObjectTagger)
+
+				cfg := map[string]interface{}{"bucket": "bucket1"}
+				for k, v := range tt.cfg {
+					cfg[k] = v
+				}
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(&config.Backend{ExtraConfig: map[string]interface{}{s3.Namespace: cfg}})
+
+				request := &proxy.Request{Path: "/sample"}
+				if tt.body != "" {
+					request.Body = io.NopCloser(strings.NewReader(tt.body))
+				}
+
+				got, err := p(context.Background(), request)
+				if !tt.wantErr(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestBackendFactory_invalidTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     map[string]interface{}
+		client  func(ctrl *gomock.Controller, opts *s3.Options) s3.ObjectGetter
+		wantErr error
+	}{
+		{
+			name:    "empty tags field",
+			cfg:     map[string]interface{}{"tags_field": ""},
+			wantErr: errors.New(`aws s3: invalid "tags_field" or "tags_header" defined`),
+		},
+		{
+			name: "client not supporting tagging",
+			cfg:  map[string]interface{}{"operation": "get_tags"},
+			client: func(ctrl *gomock.Controller, opts *s3.Options) s3.ObjectGetter {
+				return mocks.NewMockObjectGetter(ctrl)
+			},
+			wantErr: errors.New("aws s3: the s3 client does not support tagging"),
+		},
+		{
+			name: "replica client not supporting tagging",
+			cfg: map[string]interface{}{
+				"tags_field": "tags",
+				"replicas":   []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+			client: func(ctrl *gomock.Controller, opts *s3.Options) s3.ObjectGetter {
+				if opts.Bucket == "bucket2" {
+					return mocks.NewMockObjectGetter(ctrl)
+				}
+				return taggerClient{mocks.NewMockObjectGetter(ctrl), mocks.NewMockObjectTagger(ctrl)}
+			},
+			wantErr: errors.New("aws s3: the s3 client does not support tagging"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				l := mocks.NewMockLogger(ctrl)
+				l.EXPECT().Error("[BACKEND: /some-endpoint][S3]", tt.wantErr).Times(1)
+
+				cfg := map[string]interface{}{"bucket": "bucket1"}
+				for k, v := range tt.cfg {
+					cfg[k] = v
+				}
+
+				b := s3.BackendFactory(l, noopBackendFactory)
+				if tt.client != nil {
+					b = s3.BackendFactoryWithClient(
+						l, noopBackendFactory, func(opts *s3.Options) s3.ObjectGetter {
+							return tt.client(ctrl, opts)
+						},
+					)
+				}
+				b(
+					&config.Backend{
+						URLPattern:  "/some-endpoint",
+						ExtraConfig: map[string]interface{}{s3.Namespace: cfg},
+					},
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,102 @@
+package s3
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+var (
+	errInvalidTenants = errors.New(`aws s3: invalid "tenant" or "tenants" defined`)
+	errUnknownTenant  = client.HTTPResponseError{Code: http.StatusForbidden, Msg: "aws s3: unknown tenant"}
+)
+
+// forTenant returns a copy of the backend reading from the bucket of the
+// tenant resolved from the request, or a 403 error when the tenant is not
+// one of the configured ones. Backends without tenants are returned as they are.
+func (b *backend) forTenant(request *proxy.Request) (*backend, error) {
+	if b.tenants == nil {
+		return b, nil
+	}
+
+	targets, ok := b.tenants[expandKey(b.opts.Tenant, request)]
+	if !ok {
+		return nil, errUnknownTenant
+	}
+
+	tb := *b
+	tb.targets = targets
+
+	return &tb, nil
+}
+
+// getTenants parses the tenant to bucket map. Each tenant is either the name
+// of its bucket or an object with a "bucket" and optional "region",
+// "endpoint", "key", "secret" and "expected_bucket_owner", inheriting the
+// rest of the base options.
+func getTenants(cfg map[string]interface{}, base *Options) (string, map[string]Options, error) {
+	v, ok := cfg["tenants"]
+	if !ok || v == nil {
+		return "", nil, nil
+	}
+
+	tenant, ok := cfg["tenant"].(string)
+	if !ok || tenant == "" {
+		return "", nil, errInvalidTenants
+	}
+
+	vs, ok := v.(map[string]interface{})
+	if !ok || len(vs) == 0 {
+		return "", nil, errInvalidTenants
+	}
+
+	if _, ok := cfg["replicas"]; ok {
+		return "", nil, errInvalidTenants
+	}
+
+	tenants := make(map[string]Options, len(vs))
+	for name, v := range vs {
+		t := Options{AWSConfig: base.AWSConfig.Copy(), ExpectedBucketOwner: base.ExpectedBucketOwner}
+
+		switch tc := v.(type) {
+		case string:
+			t.Bucket = tc
+		case map[string]interface{}:
+			t.Bucket, _ = tc["bucket"].(string)
+
+			if region, ok := tc["region"].(string); ok && region != "" {
+				t.AWSConfig.Region = region
+			}
+
+			if endpoint, ok := tc["endpoint"].(string); ok && endpoint != "" {
+				t.AWSConfig.EndpointResolverWithOptions = endpointResolver(endpoint)
+			}
+
+			if tc["expected_bucket_owner"] != nil {
+				owner, err := getBucketOwner(tc)
+				if err != nil {
+					return "", nil, err
+				}
+				t.ExpectedBucketOwner = owner
+			}
+
+			credentials, err := getCredentials(tc)
+			if err != nil {
+				return "", nil, errInvalidTenants
+			}
+			if credentials != nil {
+				t.AWSConfig.Credentials = credentials
+			}
+		}
+
+		if name == "" || t.Bucket == "" {
+			return "", nil, errInvalidTenants
+		}
+
+		tenants[name] = t
+	}
+
+	return tenant, tenants, nil
+}
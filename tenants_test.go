@@ -0,0 +1,188 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFactoryWithClient_tenants(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenant  string
+		request *proxy.Request
+		want    *proxy.Response
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:   "tenant from header, should read from the tenant bucket",
+			tenant: "{header.X-Tenant}",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"X-Tenant": {"acme"}},
+			},
+			want: &proxy.Response{
+				Data:       map[string]interface{}{"bucket": "acme-data"},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusOK},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:   "tenant from param, should read from the tenant bucket",
+			tenant: "{tenant}",
+			request: &proxy.Request{
+				Path:   "/sample",
+				Params: map[string]string{"Tenant": "globex"},
+			},
+			want: &proxy.Response{
+				Data:       map[string]interface{}{"bucket": "globex-data"},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusOK},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:   "unknown tenant, should return 403",
+			tenant: "{header.X-Tenant}",
+			request: &proxy.Request{
+				Path:    "/sample",
+				Headers: map[string][]string{"X-Tenant": {"initech"}},
+			},
+			wantErr: wantStatusCode(http.StatusForbidden),
+		},
+		{
+			name:    "missing tenant, should return 403",
+			tenant:  "{header.X-Tenant}",
+			request: &proxy.Request{Path: "/sample"},
+			wantErr: wantStatusCode(http.StatusForbidden),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				clients := map[string]*mocks.MockObjectGetter{}
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						cl := mocks.NewMockObjectGetter(ctrl)
+						cl.EXPECT().GetObject(
+							gomock.Any(), gomock.Eq(
+								&awsS3.GetObjectInput{
+									Bucket: aws.String(opts.Bucket),
+									Key:    aws.String("sample"),
+								},
+							),
+						).AnyTimes().Return(objectOutput(`{"bucket": "`+opts.Bucket+`"}`), nil)
+						clients[opts.Bucket] = cl
+
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"tenant": tt.tenant,
+								"tenants": map[string]interface{}{
+									"acme": "acme-data",
+									"globex": map[string]interface{}{
+										"bucket": "globex-data",
+										"region": "us-east-1",
+									},
+								},
+							},
+						},
+					},
+				)
+
+				assert.Len(t, clients, 2)
+
+				got, err := p(context.Background(), tt.request)
+				if !tt.wantErr(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestBackendFactory_invalidTenants(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  map[string]interface{}
+	}{
+		{
+			name: "tenant template not defined",
+			cfg: map[string]interface{}{
+				"tenants": map[string]interface{}{"acme": "acme-data"},
+			},
+		},
+		{
+			name: "tenants not a map",
+			cfg: map[string]interface{}{
+				"tenant":  "{tenant}",
+				"tenants": []interface{}{"acme-data"},
+			},
+		},
+		{
+			name: "tenant without bucket",
+			cfg: map[string]interface{}{
+				"tenant":  "{tenant}",
+				"tenants": map[string]interface{}{"acme": map[string]interface{}{"region": "us-east-1"}},
+			},
+		},
+		{
+			name: "tenant key without secret",
+			cfg: map[string]interface{}{
+				"tenant": "{tenant}",
+				"tenants": map[string]interface{}{
+					"acme": map[string]interface{}{"bucket": "acme-data", "key": "some-key"},
+				},
+			},
+		},
+		{
+			name: "tenants along with replicas",
+			cfg: map[string]interface{}{
+				"tenant":   "{tenant}",
+				"tenants":  map[string]interface{}{"acme": "acme-data"},
+				"replicas": []interface{}{map[string]interface{}{"bucket": "bucket2"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				l := mocks.NewMockLogger(ctrl)
+				l.EXPECT().Error(
+					"[BACKEND: /some-endpoint][S3]",
+					errors.New(`aws s3: invalid "tenant" or "tenants" defined`),
+				).Times(1)
+
+				b := s3.BackendFactory(l, noopBackendFactory)
+				b(
+					&config.Backend{
+						URLPattern:  "/some-endpoint",
+						ExtraConfig: map[string]interface{}{s3.Namespace: tt.cfg},
+					},
+				)
+			},
+		)
+	}
+}
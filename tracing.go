@@ -0,0 +1,128 @@
+package s3
+
+import (
+	"context"
+	"errors"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(Namespace)
+}
+
+// withTraceContext returns the context carrying the trace of the incoming
+// request, taken from its headers when the context has no span yet.
+func withTraceContext(ctx context.Context, headers map[string][]string) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() || len(headers) == 0 {
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(headers))
+}
+
+// endSpan records the error, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// operationAttributes returns the span attributes describing the result of an
+// s3 operation, taken from its result metadata or from the error it returned.
+func operationAttributes(metadata middleware.Metadata, err error) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+
+	requestID, hostID := requestIDs(metadata, err)
+	if requestID != "" {
+		attrs = append(attrs, attribute.String("aws.request_id", requestID))
+	}
+	if hostID != "" {
+		attrs = append(attrs, attribute.String("aws.s3.host_id", hostID))
+	}
+
+	if results, ok := retry.GetAttemptResults(metadata); ok && len(results.Results) > 0 {
+		attrs = append(attrs, attribute.Int("aws.retry_count", len(results.Results)-1))
+	}
+
+	var maxAttempts *retry.MaxAttemptsError
+	if errors.As(err, &maxAttempts) {
+		attrs = append(attrs, attribute.Int("aws.retry_count", maxAttempts.Attempt-1))
+	}
+
+	return attrs
+}
+
+// requestIDs returns the x-amz-request-id and x-amz-id-2 values of an s3
+// response, taken from its result metadata or from the error it returned.
+func requestIDs(metadata middleware.Metadata, err error) (string, string) {
+	if err != nil {
+		var requestID, hostID string
+
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) {
+			requestID = respErr.ServiceRequestID()
+		}
+
+		var hostErr interface{ ServiceHostID() string }
+		if errors.As(err, &hostErr) {
+			hostID = hostErr.ServiceHostID()
+		}
+
+		return requestID, hostID
+	}
+
+	requestID, _ := awsmiddleware.GetRequestIDMetadata(metadata)
+	hostID, _ := s3.GetHostIDMetadata(metadata)
+
+	return requestID, hostID
+}
+
+// addTracingMiddleware adds a span for every attempt of an operation to the
+// middleware stack of the s3 client, propagating the trace context of the
+// request in the headers sent to s3.
+func addTracingMiddleware(stack *middleware.Stack) error {
+	mw := middleware.FinalizeMiddlewareFunc(
+		"S3BackendTracing",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+			middleware.FinalizeOutput, middleware.Metadata, error,
+		) {
+			ctx, span := newTracer().Start(
+				ctx, "s3."+awsmiddleware.GetOperationName(ctx)+" attempt",
+				trace.WithSpanKind(trace.SpanKindClient),
+			)
+
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+			}
+
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			if resp, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+				span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+			}
+			endSpan(span, err)
+
+			return out, metadata, err
+		},
+	)
+
+	// the middleware runs after signing so the propagated headers are not signed.
+	if err := stack.Finalize.Insert(mw, "Signing", middleware.After); err == nil {
+		return nil
+	}
+
+	return stack.Finalize.Add(mw, middleware.After)
+}
@@ -0,0 +1,97 @@
+package s3_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBackendFactory_tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	var gotTraceparent string
+	srv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				gotTraceparent = r.Header.Get("traceparent")
+				w.Header().Set("x-amz-request-id", "request-id")
+				w.Header().Set("x-amz-id-2", "host-id")
+				w.Header().Set("x-amz-version-id", "version-id")
+				_, _ = w.Write([]byte(`{"property1": "value1"}`))
+			},
+		),
+	)
+	defer srv.Close()
+
+	b := s3.BackendFactory(logging.NoOp, noopBackendFactory)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":   "bucket1",
+					"region":   "eu-west-1",
+					"endpoint": srv.URL,
+				},
+			},
+		},
+	)
+
+	_, err := p(
+		context.Background(), &proxy.Request{
+			Path:    "/sample",
+			Headers: map[string][]string{"Traceparent": {"00-" + traceID + "-00f067aa0ba902b7-01"}},
+		},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, gotTraceparent, traceID)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+		assert.Equal(t, traceID, s.SpanContext().TraceID().String(), s.Name())
+	}
+
+	for _, name := range []string{"s3.backend", "s3.fetch", "s3.GetObject", "s3.GetObject attempt", "s3.decode", "s3.format"} {
+		assert.Contains(t, spans, name)
+	}
+
+	getObject, ok := spans["s3.GetObject"]
+	if !assert.True(t, ok) {
+		return
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range getObject.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "bucket1", attrs["aws.s3.bucket"].AsString())
+	assert.Equal(t, "sample", attrs["aws.s3.key"].AsString())
+	assert.Equal(t, "version-id", attrs["aws.s3.version_id"].AsString())
+	assert.Equal(t, "request-id", attrs["aws.request_id"].AsString())
+	assert.Equal(t, "host-id", attrs["aws.s3.host_id"].AsString())
+	assert.Equal(t, int64(0), attrs["aws.retry_count"].AsInt64())
+	assert.Equal(t, int64(23), attrs["s3.bytes"].AsInt64())
+}
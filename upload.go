@@ -0,0 +1,155 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/luraproject/lura/v2/transport/http/client"
+)
+
+// abortTimeout bounds the cleanup of the uploads interrupted by the client.
+const abortTimeout = 30 * time.Second
+
+var (
+	errInvalidOperation  = errors.New(`aws s3: invalid "operation" defined`)
+	errInvalidUpload     = errors.New(`aws s3: invalid "part_size" or "upload_concurrency" defined`)
+	errUploadUnsupported = errors.New("aws s3: the s3 client does not support uploads")
+	errNoBody            = client.HTTPResponseError{Code: http.StatusBadRequest, Msg: "aws s3: the request has no body to upload"}
+)
+
+// putObject streams the request body into the object, in multiple parts when
+// it is larger than the part size, and returns the key, ETag, location and
+// version of the uploaded object.
+func (b *backend) putObject(ctx context.Context, request *proxy.Request) (*proxy.Response, error) {
+	t := b.targets[0]
+	uploader, ok := t.client.(ObjectUploader)
+	if !ok {
+		return nil, errUploadUnsupported
+	}
+
+	k := candidateKeys(b.opts, request)[0].key
+	if err := b.checkAccess(k); err != nil {
+		return nil, err
+	}
+
+	if request.Body == nil {
+		return nil, errNoBody
+	}
+	defer request.Body.Close()
+
+	in := &s3.PutObjectInput{
+		Bucket: &t.bucket,
+		Key:    &k,
+		Body:   request.Body,
+	}
+	if ct := http.Header(request.Headers).Get("Content-Type"); ct != "" {
+		in.ContentType = aws.String(ct)
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+	}
+	if b.opts.RequestPayer {
+		in.RequestPayer = types.RequestPayerRequester
+	}
+
+	start := time.Now()
+	out, err := manager.NewUploader(
+		uploader, func(u *manager.Uploader) {
+			if b.opts.PartSize > 0 {
+				u.PartSize = b.opts.PartSize
+			}
+			if b.opts.UploadConcurrency > 0 {
+				u.Concurrency = b.opts.UploadConcurrency
+			}
+		},
+	).Upload(ctx, in)
+	b.metrics.observe(ctx, "PutObject", t.bucket, start, 0, err)
+	b.logOperation("PutObject", t.bucket, k, start, middleware.Metadata{}, err)
+	if err != nil {
+		b.abortUpload(ctx, uploader, t, k, err)
+		return nil, err
+	}
+
+	return b.format(
+		ctx, proxy.Response{
+			Data: map[string]interface{}{
+				"key":        k,
+				"etag":       aws.ToString(out.ETag),
+				"location":   out.Location,
+				"version_id": aws.ToString(out.VersionID),
+			},
+			IsComplete: true,
+			Metadata: proxy.Metadata{
+				Headers:    map[string][]string{},
+				StatusCode: http.StatusCreated,
+			},
+		},
+	), nil
+}
+
+// abortUpload aborts the multipart upload interrupted because the client
+// disconnected. The upload manager aborts the failed uploads itself, but with
+// the request context, which is already canceled in that case.
+func (b *backend) abortUpload(ctx context.Context, uploader ObjectUploader, t target, k string, err error) {
+	var failure manager.MultiUploadFailure
+	if ctx.Err() == nil || !errors.As(err, &failure) {
+		return
+	}
+
+	abortCtx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+	defer cancel()
+
+	in := &s3.AbortMultipartUploadInput{
+		Bucket:   &t.bucket,
+		Key:      &k,
+		UploadId: aws.String(failure.UploadID()),
+	}
+	if t.owner != "" {
+		in.ExpectedBucketOwner = aws.String(t.owner)
+	}
+	if b.opts.RequestPayer {
+		in.RequestPayer = types.RequestPayerRequester
+	}
+
+	if _, err := uploader.AbortMultipartUpload(abortCtx, in); err != nil {
+		b.logger.Warning(b.logPrefix, "failed to abort the multipart upload of", k, "error:", err)
+	}
+}
+
+// getOperation parses the operation of the backend and the options of the
+// multipart uploads.
+func getOperation(cfg map[string]interface{}, opts *Options) error {
+	if v, ok := cfg["operation"]; ok {
+		operation, ok := v.(string)
+		if !ok || !operations[operation] {
+			return errInvalidOperation
+		}
+		opts.Operation = operation
+	}
+
+	if _, ok := cfg["part_size"]; ok {
+		partSize, ok := getInt(cfg, "part_size")
+		if !ok || int64(partSize) < manager.MinUploadPartSize {
+			return errInvalidUpload
+		}
+		opts.PartSize = int64(partSize)
+	}
+
+	if _, ok := cfg["upload_concurrency"]; ok {
+		concurrency, ok := getInt(cfg, "upload_concurrency")
+		if !ok || concurrency < 1 {
+			return errInvalidUpload
+		}
+		opts.UploadConcurrency = concurrency
+	}
+
+	return nil
+}
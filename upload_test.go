@@ -0,0 +1,281 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/golang/mock/gomock"
+	s3 "github.com/jbactad/krakend-s3"
+	"github.com/jbactad/krakend-s3/mocks"
+	"github.com/luraproject/lura/v2/config"
+	"github.com/luraproject/lura/v2/logging"
+	"github.com/luraproject/lura/v2/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+// uploaderClient is an s3 client supporting uploads.
+type uploaderClient struct {
+	*mocks.MockObjectGetter
+	*mocks.MockObjectUploader
+}
+
+func TestBackendFactoryWithClient_put(t *testing.T) {
+	const partSize = 5 * 1024 * 1024
+
+	tests := []struct {
+		name    string
+		body    io.Reader
+		setup   func(t *testing.T, client *mocks.MockObjectUploader)
+		want    *proxy.Response
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name: "small body, should upload it in a single part",
+			body: strings.NewReader(`{"property1": "value1"}`),
+			setup: func(t *testing.T, client *mocks.MockObjectUploader) {
+				client.EXPECT().PutObject(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(_ context.Context, in *awsS3.PutObjectInput, _ ...func(*awsS3.Options)) (*awsS3.PutObjectOutput, error) {
+						assert.Equal(t, "bucket1", aws.ToString(in.Bucket))
+						assert.Equal(t, "uploads/sample.json", aws.ToString(in.Key))
+						assert.Equal(t, "application/json", aws.ToString(in.ContentType))
+
+						body, _ := io.ReadAll(in.Body)
+						assert.Equal(t, `{"property1": "value1"}`, string(body))
+
+						return &awsS3.PutObjectOutput{ETag: aws.String(`"etag"`), VersionId: aws.String("v1")}, nil
+					},
+				)
+			},
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"key":        "uploads/sample.json",
+					"etag":       `"etag"`,
+					"location":   "",
+					"version_id": "v1",
+				},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusCreated},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "large body, should upload it in multiple parts",
+			body: bytes.NewReader(make([]byte, 2*partSize+1)),
+			setup: func(t *testing.T, client *mocks.MockObjectUploader) {
+				client.EXPECT().CreateMultipartUpload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(&awsS3.CreateMultipartUploadOutput{UploadId: aws.String("upload1")}, nil)
+				client.EXPECT().UploadPart(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).
+					Return(&awsS3.UploadPartOutput{ETag: aws.String(`"part"`)}, nil)
+				client.EXPECT().CompleteMultipartUpload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(_ context.Context, in *awsS3.CompleteMultipartUploadInput, _ ...func(*awsS3.Options)) (*awsS3.CompleteMultipartUploadOutput, error) {
+						assert.Equal(t, "upload1", aws.ToString(in.UploadId))
+						assert.Len(t, in.MultipartUpload.Parts, 3)
+
+						return &awsS3.CompleteMultipartUploadOutput{ETag: aws.String(`"etag-3"`)}, nil
+					},
+				)
+			},
+			want: &proxy.Response{
+				Data: map[string]interface{}{
+					"key":        "uploads/sample.json",
+					"etag":       `"etag-3"`,
+					"location":   "",
+					"version_id": "",
+				},
+				IsComplete: true,
+				Metadata:   proxy.Metadata{Headers: map[string][]string{}, StatusCode: http.StatusCreated},
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name: "part upload failed, should abort the upload",
+			body: bytes.NewReader(make([]byte, 2*partSize+1)),
+			setup: func(t *testing.T, client *mocks.MockObjectUploader) {
+				client.EXPECT().CreateMultipartUpload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(&awsS3.CreateMultipartUploadOutput{UploadId: aws.String("upload1")}, nil)
+				client.EXPECT().UploadPart(gomock.Any(), gomock.Any(), gomock.Any()).MinTimes(1).
+					Return(nil, errors.New("connection reset"))
+				client.EXPECT().AbortMultipartUpload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(&awsS3.AbortMultipartUploadOutput{}, nil)
+			},
+			wantErr: assert.Error,
+		},
+		{
+			name:    "no body, should return 400",
+			setup:   func(t *testing.T, client *mocks.MockObjectUploader) {},
+			wantErr: wantStatusCode(http.StatusBadRequest),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				cl := uploaderClient{mocks.NewMockObjectGetter(ctrl), mocks.NewMockObjectUploader(ctrl)}
+				tt.setup(t, cl.MockObjectUploader)
+
+				b := s3.BackendFactoryWithClient(
+					logging.NoOp, noopBackendFactory,
+					func(opts *s3.Options) s3.ObjectGetter {
+						return cl
+					},
+				)
+				p := b(
+					&config.Backend{
+						ExtraConfig: map[string]interface{}{
+							s3.Namespace: map[string]interface{}{
+								"bucket":             "bucket1",
+								"operation":          "put",
+								"part_size":          float64(partSize),
+								"upload_concurrency": 1,
+							},
+						},
+					},
+				)
+
+				request := &proxy.Request{
+					Path:    "/uploads/sample.json",
+					Headers: map[string][]string{"Content-Type": {"application/json"}},
+				}
+				if tt.body != nil {
+					request.Body = io.NopCloser(tt.body)
+				}
+
+				got, err := p(context.Background(), request)
+				if !tt.wantErr(t, err) {
+					return
+				}
+
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestBackendFactoryWithClient_putClientDisconnected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	cl := uploaderClient{mocks.NewMockObjectGetter(ctrl), mocks.NewMockObjectUploader(ctrl)}
+	cl.MockObjectUploader.EXPECT().CreateMultipartUpload(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+		Return(&awsS3.CreateMultipartUploadOutput{UploadId: aws.String("upload1")}, nil)
+	cl.MockObjectUploader.EXPECT().UploadPart(gomock.Any(), gomock.Any(), gomock.Any()).MinTimes(1).DoAndReturn(
+		func(_ context.Context, _ *awsS3.UploadPartInput, _ ...func(*awsS3.Options)) (*awsS3.UploadPartOutput, error) {
+			cancel()
+			return nil, context.Canceled
+		},
+	)
+
+	mu := sync.Mutex{}
+	aborted := false
+	cl.MockObjectUploader.EXPECT().AbortMultipartUpload(gomock.Any(), gomock.Any(), gomock.Any()).MinTimes(1).DoAndReturn(
+		func(ctx context.Context, in *awsS3.AbortMultipartUploadInput, _ ...func(*awsS3.Options)) (*awsS3.AbortMultipartUploadOutput, error) {
+			assert.Equal(t, "upload1", aws.ToString(in.UploadId))
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			mu.Lock()
+			aborted = true
+			mu.Unlock()
+
+			return &awsS3.AbortMultipartUploadOutput{}, nil
+		},
+	)
+
+	b := s3.BackendFactoryWithClient(
+		logging.NoOp, noopBackendFactory,
+		func(opts *s3.Options) s3.ObjectGetter {
+			return cl
+		},
+	)
+	p := b(
+		&config.Backend{
+			ExtraConfig: map[string]interface{}{
+				s3.Namespace: map[string]interface{}{
+					"bucket":    "bucket1",
+					"operation": "put",
+				},
+			},
+		},
+	)
+
+	_, err := p(
+		ctx, &proxy.Request{
+			Path: "/uploads/sample.json",
+			Body: io.NopCloser(bytes.NewReader(make([]byte, 11*1024*1024))),
+		},
+	)
+	assert.Error(t, err)
+	assert.True(t, aborted)
+}
+
+func TestBackendFactory_invalidUpload(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     map[string]interface{}
+		client  func(ctrl *gomock.Controller) s3.ObjectGetter
+		wantErr error
+	}{
+		{
+			name:    "unknown operation",
+			cfg:     map[string]interface{}{"operation": "post"},
+			wantErr: errors.New(`aws s3: invalid "operation" defined`),
+		},
+		{
+			name:    "part size below the minimum",
+			cfg:     map[string]interface{}{"operation": "put", "part_size": 1024},
+			wantErr: errors.New(`aws s3: invalid "part_size" or "upload_concurrency" defined`),
+		},
+		{
+			name:    "no upload concurrency",
+			cfg:     map[string]interface{}{"operation": "put", "upload_concurrency": 0},
+			wantErr: errors.New(`aws s3: invalid "part_size" or "upload_concurrency" defined`),
+		},
+		{
+			name: "client not supporting uploads",
+			cfg:  map[string]interface{}{"operation": "put"},
+			client: func(ctrl *gomock.Controller) s3.ObjectGetter {
+				return mocks.NewMockObjectGetter(ctrl)
+			},
+			wantErr: errors.New("aws s3: the s3 client does not support uploads"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				l := mocks.NewMockLogger(ctrl)
+				l.EXPECT().Error("[BACKEND: /some-endpoint][S3]", tt.wantErr).Times(1)
+
+				cfg := map[string]interface{}{"bucket": "bucket1"}
+				for k, v := range tt.cfg {
+					cfg[k] = v
+				}
+
+				b := s3.BackendFactory(l, noopBackendFactory)
+				if tt.client != nil {
+					b = s3.BackendFactoryWithClient(
+						l, noopBackendFactory, func(opts *s3.Options) s3.ObjectGetter {
+							return tt.client(ctrl)
+						},
+					)
+				}
+				b(
+					&config.Backend{
+						URLPattern:  "/some-endpoint",
+						ExtraConfig: map[string]interface{}{s3.Namespace: cfg},
+					},
+				)
+			},
+		)
+	}
+}